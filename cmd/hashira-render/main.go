@@ -0,0 +1,156 @@
+// hashira-render renders a saved world (or Tiled map) to PNG without a browser.
+//
+//	hashira-render -in world.json -tileset tileset=tiles.png -map main -zoom 2 -out preview.png
+//
+// Tileset layout can follow the path, otherwise the one from the map or saved world is used:
+//
+//	-tileset terrain=terrain.png,margin=1,spacing=2,columns=8,count=64
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hrender"
+	"github.com/qbart/hashira/htiled"
+)
+
+type tilesetFlag struct {
+	path   string
+	layout hashira.TilesetLayout
+}
+
+// tilesetFlags collects repeated -tileset name=path[,margin=N,spacing=N,columns=N,count=N] flags.
+type tilesetFlags map[string]tilesetFlag
+
+func (t tilesetFlags) String() string {
+	return fmt.Sprint(map[string]tilesetFlag(t))
+}
+
+func (t tilesetFlags) Set(value string) error {
+	parts := strings.Split(value, ",")
+	name, path, ok := strings.Cut(parts[0], "=")
+	if !ok {
+		name, path = hashira.DefaultTileset, parts[0]
+	}
+	flag := tilesetFlag{path: path}
+	for _, option := range parts[1:] {
+		key, v, _ := strings.Cut(option, "=")
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid tileset option %q: %v", option, err)
+		}
+		switch key {
+		case "margin":
+			flag.layout.Margin = n
+		case "spacing":
+			flag.layout.Spacing = n
+		case "columns":
+			flag.layout.Columns = n
+		case "count":
+			flag.layout.TileCount = n
+		default:
+			return fmt.Errorf("unknown tileset option: %v", key)
+		}
+	}
+	if err := flag.layout.Validate(); err != nil {
+		return err
+	}
+	t[name] = flag
+	return nil
+}
+
+func main() {
+	tilesets := tilesetFlags{}
+	in := flag.String("in", "", "saved world (json or binary) or Tiled map (.tmx, .tmj)")
+	mapName := flag.String("map", "", "map to render, may be omitted when there is only one map")
+	zoom := flag.Float64("zoom", 1, "zoom, 2 means every texel is 2x2 pixels")
+	region := flag.String("region", "", "region in tiles: x,y,width,height (default whole map)")
+	background := flag.String("background", "#ffffff", "background color")
+	out := flag.String("out", "map.png", "output PNG")
+	flag.Var(tilesets, "tileset", "tileset PNG as name=path[,margin=N,spacing=N,columns=N,count=N], name defaults to \""+hashira.DefaultTileset+"\" (repeatable)")
+	flag.Parse()
+
+	if err := run(*in, *mapName, tilesets, float32(*zoom), *region, *background, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(in, mapName string, tilesets tilesetFlags, zoom float32, regionFlag, background, out string) error {
+	if in == "" {
+		return fmt.Errorf("missing -in")
+	}
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return fmt.Errorf("error reading map: %v", err)
+	}
+
+	w := hashira.New()
+	for name, t := range tilesets {
+		b, err := os.ReadFile(t.path)
+		if err != nil {
+			return fmt.Errorf("error reading tileset: %v", err)
+		}
+		if _, err := w.Resources.LoadTileset(name, b, t.layout); err != nil {
+			return fmt.Errorf("error loading tileset %v: %v", name, err)
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(in)) {
+	case ".tmx", ".tmj":
+		if mapName == "" {
+			mapName = strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))
+		}
+		err = htiled.Import(w, mapName, data)
+	default:
+		err = w.Load(data)
+	}
+	if err != nil {
+		return fmt.Errorf("error loading map: %v", err)
+	}
+	// layouts given on the command line win over the ones from the map
+	for name, t := range tilesets {
+		if t.layout != (hashira.TilesetLayout{}) {
+			w.Resources.SetTilesetLayout(name, t.layout)
+			w.Resync()
+		}
+	}
+
+	if mapName == "" {
+		names := w.Maps.Keys()
+		if len(names) != 1 {
+			sort.Strings(names)
+			return fmt.Errorf("-map is required, available maps: %v", strings.Join(names, ", "))
+		}
+		mapName = names[0]
+	}
+
+	var region hrender.Region
+	if regionFlag != "" {
+		_, err := fmt.Sscanf(regionFlag, "%d,%d,%d,%d", &region.X, &region.Y, &region.Width, &region.Height)
+		if err != nil {
+			return fmt.Errorf("invalid region %q: %v", regionFlag, err)
+		}
+	}
+
+	img, err := hrender.RenderMap(w, mapName, region, zoom, hgl.ParseHEXColor(background))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("error encoding image: %v", err)
+	}
+	return os.WriteFile(out, buf.Bytes(), 0644)
+}
@@ -2,10 +2,10 @@ module github.com/qbart/hashira
 
 go 1.20
 
-require github.com/go-gl/mathgl v1.0.0
-
 require (
-	github.com/SoftKiwiGames/risky v1.0.0 // indirect
-	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
-	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
+	github.com/SoftKiwiGames/risky v1.0.0
+	github.com/go-gl/mathgl v1.0.0
+	golang.org/x/exp v0.0.0-20230905200255-921286631fa9
 )
+
+require golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
@@ -1,14 +1,31 @@
 package hashira
 
+import "fmt"
+
 type Animation struct {
 	Frames []int   `json:"frames"`
 	Delay  float32 `json:"delay"`
 }
 
+// BaseTile is the first frame of the animation.
+// Tiles placed on a layer with this id are animated automatically.
+func (a *Animation) BaseTile() int {
+	return a.Frames[0]
+}
+
+func (a *Animation) Validate() error {
+	if len(a.Frames) < 2 {
+		return fmt.Errorf("animation needs at least 2 frames, got %d", len(a.Frames))
+	}
+	if a.Delay <= 0 {
+		return fmt.Errorf("invalid animation delay: %v", a.Delay)
+	}
+	return nil
+}
+
 type AnimatedTile struct {
 	*Animation
 
-	Layer      int
 	X          int
 	Y          int
 	FrameIndex int
@@ -32,26 +49,3 @@ func (a *AnimatedTile) Update(dt float32) bool {
 func (a *AnimatedTile) Tile() int {
 	return a.Animation.Frames[a.FrameIndex]
 }
-
-// o.animatedTiles = make([]*AnimatedTile, 0)
-// for l, layer := range o.config.Layers {
-// 	for y, row := range layer.Data {
-// 		for x, tile := range row {
-// 			for _, animation := range o.config.Tiles.Animations {
-// 				if len(animation.Frames) > 1 {
-// 					frame := animation.Frames[0]
-// 					if tile == frame {
-// 						animation := animation
-// 						o.animatedTiles = append(o.animatedTiles, &AnimatedTile{
-// 							Animation:  &animation,
-// 							X:          x,
-// 							Y:          o.config.MapHeight() - y - 1,
-// 							Layer:      l,
-// 							FrameIndex: 0,
-// 						})
-// 					}
-// 				}
-// 			}
-// 		}
-// 	}
-// }
@@ -1,18 +1,82 @@
 package hashira
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/qbart/hashira/hgl"
 	"github.com/qbart/hashira/hmath"
 )
 
+const (
+	minZoom         = 0.5
+	maxZoom         = 20
+	defaultFriction = 6
+)
+
 type Camera2D struct {
 	ViewMatrix hmath.Matrix4
 	Position   hmath.Vertex
 	Zoom       float32
+	// PixelPerfect snaps position to whole texels, zoom to multiples of ZoomStep
+	// and letterboxes the screen so it shows even number of whole texels.
+	PixelPerfect bool
+	ZoomStep     float32
+	// Bounds limit the visible area, nil means no limit.
+	Bounds *Rect
+	// Friction slows down drag inertia, velocity decays by exp(-Friction*dt).
+	Friction float32
+	// position before snapping
+	exact hmath.Vertex
+
+	transition *cameraTransition
+	follow     *cameraFollow
+	// drag movement since the last update, velocity in world units per second
+	dragging bool
+	dragged  [2]float32
+	velocity [2]float32
+}
+
+func NewCamera2D() *Camera2D {
+	return &Camera2D{
+		ViewMatrix: hmath.IdentityMatrix(),
+		Zoom:       1,
+		ZoomStep:   1,
+		Friction:   defaultFriction,
+	}
+}
+
+// SetPixelPerfect toggles pixel perfect mode, zoom step 0 means integer zoom.
+func (c *Camera2D) SetPixelPerfect(enabled bool, zoomStep float32) error {
+	if zoomStep < 0 || zoomStep > maxZoom {
+		return fmt.Errorf("invalid zoom step: %v", zoomStep)
+	}
+	if zoomStep == 0 {
+		zoomStep = 1
+	}
+	c.PixelPerfect = enabled
+	c.ZoomStep = zoomStep
+	c.setZoom(c.Zoom)
+	c.updateView()
+	return nil
 }
 
 func (c *Camera2D) ZoomBy(delta float32) {
-	c.Zoom = hmath.Clamp(c.Zoom+delta, 0.5, 20)
+	c.stopZoomTransition()
+	if c.PixelPerfect {
+		// at least one step in the direction of delta
+		steps := float32(math.Round(float64(delta / c.ZoomStep)))
+		if steps == 0 && delta > 0 {
+			steps = 1
+		}
+		if steps == 0 && delta < 0 {
+			steps = -1
+		}
+		c.SetZoom(c.Zoom + steps*c.ZoomStep)
+		return
+	}
+
+	c.Zoom = hmath.Clamp(c.Zoom+delta, minZoom, maxZoom)
 	// c.Zoom = hmath.Clamp(c.Zoom+delta, 1, 20)
 
 	// correction for going from 0.5 to 1
@@ -22,35 +86,110 @@ func (c *Camera2D) ZoomBy(delta float32) {
 }
 
 func (c *Camera2D) SetZoom(zoom float32) {
+	c.stopZoomTransition()
+	c.setZoom(zoom)
+}
+
+func (c *Camera2D) setZoom(zoom float32) {
+	if c.PixelPerfect {
+		step := c.ZoomStep
+		zoom = float32(math.Round(float64(zoom/step))) * step
+		zoom = hmath.Clamp(zoom, step, float32(math.Floor(float64(maxZoom/step)))*step)
+	}
 	c.Zoom = zoom
 }
 
 func (c *Camera2D) Translate(x, y float32) {
-	c.Position[0] = -x
-	c.Position[1] = -y
-	c.Position[2] = 0
-	c.ViewMatrix = hmath.TranslationMatrix(c.Position)
+	c.transition = nil
+	c.velocity = [2]float32{}
+	c.setCenter(x, y)
 }
 
 func (c *Camera2D) TranslateBy(dx, dy float32) {
-	c.Position[0] += -dx
-	c.Position[1] += -dy
-	c.Position[2] = 0
+	c.transition = nil
+	c.velocity = [2]float32{}
+	x, y := c.Center()
+	c.setCenter(x+dx, y+dy)
+}
+
+// Center returns world position in the center of the view (before snapping).
+func (c *Camera2D) Center() (x, y float32) {
+	return -c.exact[0], -c.exact[1]
+}
+
+func (c *Camera2D) setCenter(x, y float32) {
+	c.exact = hmath.Vertex{-x, -y, 0}
+	c.updateView()
+}
+
+func (c *Camera2D) updateView() {
+	c.Position = c.exact
+	if c.PixelPerfect {
+		c.Position[0] = float32(math.Round(float64(c.Position[0])))
+		c.Position[1] = float32(math.Round(float64(c.Position[1])))
+	}
 	c.ViewMatrix = hmath.TranslationMatrix(c.Position)
 }
 
+// Viewport returns part of the screen in pixels (origin at top left) showing the world,
+// in pixel perfect mode the rest of the screen is letterboxed.
+func (c *Camera2D) Viewport(screen *hgl.Screen) (x, y, width, height int) {
+	if !c.PixelPerfect {
+		return 0, 0, screen.Width, screen.Height
+	}
+	width = letterbox(screen.Width, c.Zoom)
+	height = letterbox(screen.Height, c.Zoom)
+	return (screen.Width - width) / 2, (screen.Height - height) / 2, width, height
+}
+
+// letterbox returns the largest size in pixels fitting even number of texels,
+// so viewport center is on a texel edge.
+func letterbox(size int, zoom float32) int {
+	texels := int(float32(size)/zoom) / 2 * 2
+	if texels == 0 {
+		return size
+	}
+	return int(math.Round(float64(float32(texels) * zoom)))
+}
+
 func (c *Camera2D) Projection(screen *hgl.Screen) hmath.Matrix4 {
 	w := float32(screen.Width)
 	h := float32(screen.Height)
-	hh := h / 2
-	wh := w / 2
+	cx := w / 2
+	cy := h / 2
+	if c.PixelPerfect {
+		// whole pixel center of the viewport
+		x, y, vw, vh := c.Viewport(screen)
+		cx = float32(x + vw/2)
+		cy = float32(y + vh/2)
+	}
 	scale := 1 / c.Zoom
 
 	return hmath.Ortho(
-		-wh*scale,
-		wh*scale,
-		-hh*scale,
-		hh*scale,
+		-cx*scale,
+		(w-cx)*scale,
+		-(h-cy)*scale,
+		cy*scale,
 		-100, 100,
 	)
 }
+
+// ScreenToWorld converts screen position in pixels (origin at top left) to world position.
+func (c *Camera2D) ScreenToWorld(screen *hgl.Screen, x, y float32) (float32, float32) {
+	ndc := hmath.Vertex{
+		2*x/float32(screen.Width) - 1,
+		1 - 2*y/float32(screen.Height),
+		0,
+	}
+	inv := c.Projection(screen).Mul(c.ViewMatrix).Inverse()
+	p := inv.TransformPoint(ndc)
+	return p[0], p[1]
+}
+
+// WorldToScreen converts world position to screen position in pixels (origin at top left).
+func (c *Camera2D) WorldToScreen(screen *hgl.Screen, x, y float32) (float32, float32) {
+	ndc := c.Projection(screen).Mul(c.ViewMatrix).TransformPoint(hmath.Vertex{x, y, 0})
+	sx := (ndc[0] + 1) / 2 * float32(screen.Width)
+	sy := (1 - ndc[1]) / 2 * float32(screen.Height)
+	return sx, sy
+}
@@ -0,0 +1,199 @@
+package hashira
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+// below this speed (world units per second) inertia stops
+const minInertiaSpeed = 1
+
+// cameraTransition moves the camera center (and zoom when toZoom is set) over time.
+type cameraTransition struct {
+	fromX, fromY, fromZoom float32
+	toX, toY, toZoom       float32
+	elapsed                float32
+	duration               float32
+	easing                 hmath.Easing
+}
+
+// cameraFollow pulls the camera center towards the target.
+type cameraFollow struct {
+	x, y  float32
+	speed float32
+}
+
+// MoveTo starts transition of the camera center to given world position,
+// zoom 0 keeps the current zoom, other zoom is clamped to the range of ZoomBy. Duration is in seconds, 0 moves immediately.
+func (c *Camera2D) MoveTo(x, y, zoom, duration float32, easing string) error {
+	ease := hmath.EaseLinear
+	if easing != "" {
+		var ok bool
+		if ease, ok = hmath.Easings[easing]; !ok {
+			return fmt.Errorf("unknown easing: %v", easing)
+		}
+	}
+	if duration < 0 {
+		return fmt.Errorf("invalid duration: %v", duration)
+	}
+	if zoom < 0 {
+		return fmt.Errorf("invalid zoom: %v", zoom)
+	}
+	if zoom > 0 {
+		// same range as ZoomBy, pixel perfect zoom is snapped to steps while moving
+		zoom = hmath.Clamp(zoom, minZoom, maxZoom)
+	}
+
+	c.follow = nil
+	c.velocity = [2]float32{}
+	fromX, fromY := c.Center()
+	c.transition = &cameraTransition{
+		fromX:    fromX,
+		fromY:    fromY,
+		fromZoom: c.Zoom,
+		toX:      x,
+		toY:      y,
+		toZoom:   zoom,
+		duration: duration,
+		easing:   ease,
+	}
+	if duration == 0 {
+		c.updateTransition(0)
+	}
+	return nil
+}
+
+// Follow keeps moving the camera center towards the target, speed is the fraction
+// of the distance covered in 1/speed seconds (0 means default).
+func (c *Camera2D) Follow(x, y, speed float32) error {
+	if speed < 0 {
+		return fmt.Errorf("invalid follow speed: %v", speed)
+	}
+	if speed == 0 {
+		speed = 5
+	}
+	c.transition = nil
+	c.velocity = [2]float32{}
+	c.follow = &cameraFollow{x: x, y: y, speed: speed}
+	return nil
+}
+
+func (c *Camera2D) StopFollow() {
+	c.follow = nil
+}
+
+// Drag moves the camera as dragged by the user,
+// it stops transitions and following, inertia continues the movement after ReleaseDrag.
+func (c *Camera2D) Drag(dx, dy float32) {
+	c.transition = nil
+	c.follow = nil
+	c.dragging = true
+	c.dragged[0] += dx
+	c.dragged[1] += dy
+	x, y := c.Center()
+	c.setCenter(x+dx, y+dy)
+}
+
+// ReleaseDrag ends dragging, the camera keeps moving with drag velocity slowed down by friction.
+func (c *Camera2D) ReleaseDrag() {
+	c.dragging = false
+	c.dragged = [2]float32{}
+	if c.Friction <= 0 {
+		c.velocity = [2]float32{}
+	}
+}
+
+// Update advances transitions, following and inertia by dt seconds
+// and keeps the view within Bounds.
+func (c *Camera2D) Update(dt float32, screen *hgl.Screen) {
+	switch {
+	case c.transition != nil:
+		c.updateTransition(dt)
+
+	case c.follow != nil:
+		x, y := c.Center()
+		// frame rate independent exponential smoothing
+		t := 1 - float32(math.Exp(float64(-c.follow.speed*dt)))
+		c.setCenter(hmath.Lerp(x, c.follow.x, t), hmath.Lerp(y, c.follow.y, t))
+
+	case c.dragging:
+		if dt > 0 {
+			// averaged as pointer events don't arrive every frame
+			for i := range c.velocity {
+				c.velocity[i] = (c.velocity[i] + c.dragged[i]/dt) / 2
+			}
+		}
+		c.dragged = [2]float32{}
+
+	case c.velocity != [2]float32{}:
+		x, y := c.Center()
+		c.setCenter(x+c.velocity[0]*dt, y+c.velocity[1]*dt)
+		decay := float32(math.Exp(float64(-c.Friction * dt)))
+		c.velocity[0] *= decay
+		c.velocity[1] *= decay
+		if math.Hypot(float64(c.velocity[0]), float64(c.velocity[1])) < minInertiaSpeed {
+			c.velocity = [2]float32{}
+		}
+	}
+
+	c.clampToBounds(screen)
+}
+
+func (c *Camera2D) updateTransition(dt float32) {
+	tr := c.transition
+	tr.elapsed += dt
+	t := float32(1)
+	if tr.duration > 0 {
+		t = hmath.Clamp01(tr.elapsed / tr.duration)
+	}
+	e := tr.easing(t)
+	if tr.toZoom > 0 {
+		c.setZoom(hmath.Lerp(tr.fromZoom, tr.toZoom, e))
+	}
+	c.setCenter(hmath.Lerp(tr.fromX, tr.toX, e), hmath.Lerp(tr.fromY, tr.toY, e))
+	if t >= 1 {
+		c.transition = nil
+	}
+}
+
+// stopZoomTransition stops transition changing zoom, zoom set by the user wins.
+func (c *Camera2D) stopZoomTransition() {
+	if c.transition != nil && c.transition.toZoom > 0 {
+		c.transition = nil
+	}
+}
+
+// clampToBounds moves the center so the viewport stays within Bounds,
+// viewport larger than Bounds is centered on them.
+func (c *Camera2D) clampToBounds(screen *hgl.Screen) {
+	if c.Bounds == nil {
+		return
+	}
+	_, _, w, h := c.Viewport(screen)
+	x, y := c.Center()
+	cx, hitX := clampCenter(x, float32(w)/2/c.Zoom, c.Bounds.MinX, c.Bounds.MaxX)
+	cy, hitY := clampCenter(y, float32(h)/2/c.Zoom, c.Bounds.MinY, c.Bounds.MaxY)
+	// inertia stops at the edge
+	if hitX {
+		c.velocity[0] = 0
+	}
+	if hitY {
+		c.velocity[1] = 0
+	}
+	if hitX || hitY {
+		c.setCenter(cx, cy)
+	}
+}
+
+func clampCenter(center, half, min, max float32) (float32, bool) {
+	clamped := center
+	if max-min <= 2*half {
+		clamped = (min + max) / 2
+	} else {
+		clamped = hmath.Clamp(center, min+half, max-half)
+	}
+	return clamped, clamped != center
+}
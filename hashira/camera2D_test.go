@@ -0,0 +1,330 @@
+package hashira
+
+import (
+	"testing"
+
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+func TestCamera2DScreenToWorld(t *testing.T) {
+	screen := &hgl.Screen{Width: 224, Height: 256, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Translate(56, 64)
+	camera.SetZoom(2)
+
+	tests := []struct {
+		sx, sy float32
+		wx, wy float32
+	}{
+		{112, 128, 56, 64},
+		{0, 0, 0, 128},
+		{224, 256, 112, 0},
+		{224, 0, 112, 128},
+	}
+
+	for _, tt := range tests {
+		wx, wy := camera.ScreenToWorld(screen, tt.sx, tt.sy)
+		if !hmath.CloseTo(wx, tt.wx, 0.01) || !hmath.CloseTo(wy, tt.wy, 0.01) {
+			t.Errorf("screen (%v, %v): got world (%v, %v), want (%v, %v)", tt.sx, tt.sy, wx, wy, tt.wx, tt.wy)
+		}
+
+		sx, sy := camera.WorldToScreen(screen, wx, wy)
+		if !hmath.CloseTo(sx, tt.sx, 0.01) || !hmath.CloseTo(sy, tt.sy, 0.01) {
+			t.Errorf("world (%v, %v): got screen (%v, %v), want (%v, %v)", wx, wy, sx, sy, tt.sx, tt.sy)
+		}
+	}
+}
+
+func TestCamera2DTranslateBy(t *testing.T) {
+	camera := NewCamera2D()
+	camera.Translate(10, 20)
+	camera.TranslateBy(5, -5)
+
+	if camera.Position != (hmath.Vertex{-15, -15, 0}) {
+		t.Errorf("got position %v, want [-15 -15 0]", camera.Position)
+	}
+	p := camera.ViewMatrix.TransformPoint(hmath.Vertex{15, 15, 0})
+	if p != (hmath.Vertex{0, 0, 0}) {
+		t.Errorf("camera target is not centered in view: %v", p)
+	}
+}
+
+func TestCamera2DZoomBy(t *testing.T) {
+	camera := NewCamera2D()
+
+	camera.ZoomBy(-1)
+	if camera.Zoom != 0.5 {
+		t.Errorf("got zoom %v, want min zoom 0.5", camera.Zoom)
+	}
+
+	camera.ZoomBy(1)
+	if camera.Zoom != 1 {
+		t.Errorf("got zoom %v, want 1 after going up from 0.5", camera.Zoom)
+	}
+
+	camera.ZoomBy(100)
+	if camera.Zoom != 20 {
+		t.Errorf("got zoom %v, want max zoom 20", camera.Zoom)
+	}
+}
+
+func TestCamera2DProjection(t *testing.T) {
+	screen := &hgl.Screen{Width: 200, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.SetZoom(2)
+
+	p := camera.Projection(screen).TransformPoint(hmath.Vertex{50, 25, 0})
+
+	if !hmath.CloseTo(p[0], 1, 0.001) || !hmath.CloseTo(p[1], 1, 0.001) {
+		t.Errorf("got ndc %v, want top right corner", p)
+	}
+}
+
+func TestCamera2DPixelPerfect(t *testing.T) {
+	camera := NewCamera2D()
+	camera.SetZoom(2.4)
+	camera.Translate(10.3, 20.6)
+	if err := camera.SetPixelPerfect(true, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if camera.Zoom != 2 {
+		t.Errorf("got zoom %v, want 2", camera.Zoom)
+	}
+	if camera.Position != (hmath.Vertex{-10, -21, 0}) {
+		t.Errorf("got position %v, want [-10 -21 0]", camera.Position)
+	}
+
+	// sub texel movement is kept
+	camera.TranslateBy(0.4, 0)
+	camera.TranslateBy(0.4, 0)
+	if camera.Position != (hmath.Vertex{-11, -21, 0}) {
+		t.Errorf("got position %v, want [-11 -21 0]", camera.Position)
+	}
+
+	camera.ZoomBy(0.1)
+	if camera.Zoom != 3 {
+		t.Errorf("got zoom %v, want 3 after small zoom in", camera.Zoom)
+	}
+	camera.ZoomBy(-100)
+	if camera.Zoom != 1 {
+		t.Errorf("got zoom %v, want min zoom 1", camera.Zoom)
+	}
+
+	if err := camera.SetPixelPerfect(true, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	camera.ZoomBy(-1)
+	if camera.Zoom != 0.5 {
+		t.Errorf("got zoom %v, want 0.5 with zoom step 0.5", camera.Zoom)
+	}
+
+	if err := camera.SetPixelPerfect(true, -1); err == nil {
+		t.Error("expected error for negative zoom step")
+	}
+
+	if err := camera.SetPixelPerfect(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !hmath.CloseTo(camera.Position[0], -11.1, 0.001) || !hmath.CloseTo(camera.Position[1], -20.6, 0.001) {
+		t.Errorf("got position %v, want exact position [-11.1 -20.6 0]", camera.Position)
+	}
+}
+
+func TestCamera2DPixelPerfectViewport(t *testing.T) {
+	screen := &hgl.Screen{Width: 101, Height: 50, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+
+	x, y, w, h := camera.Viewport(screen)
+	if x != 0 || y != 0 || w != 101 || h != 50 {
+		t.Errorf("got viewport %v %v %v %v, want whole screen", x, y, w, h)
+	}
+
+	camera.SetPixelPerfect(true, 0)
+	camera.SetZoom(3)
+	camera.Translate(7.2, 3.9)
+
+	// 32x16 texels
+	x, y, w, h = camera.Viewport(screen)
+	if x != 2 || y != 1 || w != 96 || h != 48 {
+		t.Errorf("got viewport %v %v %v %v, want 2 1 96 48", x, y, w, h)
+	}
+
+	// viewport edges are on texel edges
+	wx, wy := camera.ScreenToWorld(screen, float32(x), float32(y))
+	if !hmath.CloseTo(wx, -9, 0.001) || !hmath.CloseTo(wy, 12, 0.001) {
+		t.Errorf("got viewport top left (%v, %v), want (-9, 12)", wx, wy)
+	}
+	sx, sy := camera.WorldToScreen(screen, 1, 1)
+	if !hmath.CloseTo(sx, 32, 0.001) || !hmath.CloseTo(sy, 34, 0.001) {
+		t.Errorf("got texel corner at (%v, %v), want whole pixels (32, 34)", sx, sy)
+	}
+
+	rect := camera.VisibleRect(screen)
+	want := Rect{MinX: -9, MinY: -4, MaxX: 23, MaxY: 12}
+	if !hmath.CloseTo(rect.MinX, want.MinX, 0.001) || !hmath.CloseTo(rect.MinY, want.MinY, 0.001) ||
+		!hmath.CloseTo(rect.MaxX, want.MaxX, 0.001) || !hmath.CloseTo(rect.MaxY, want.MaxY, 0.001) {
+		t.Errorf("got visible rect %v, want %v", rect, want)
+	}
+}
+
+func TestCamera2DMoveTo(t *testing.T) {
+	screen := &hgl.Screen{Width: 100, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Translate(0, 0)
+
+	if err := camera.MoveTo(100, 50, 3, 2, "inOutQuad"); err != nil {
+		t.Fatal(err)
+	}
+	camera.Update(1, screen)
+	if x, y := camera.Center(); !hmath.CloseTo(x, 50, 0.001) || !hmath.CloseTo(y, 25, 0.001) {
+		t.Errorf("got center (%v, %v) in the middle of transition, want (50, 25)", x, y)
+	}
+	if !hmath.CloseTo(camera.Zoom, 2, 0.001) {
+		t.Errorf("got zoom %v in the middle of transition, want 2", camera.Zoom)
+	}
+
+	camera.Update(5, screen)
+	if x, y := camera.Center(); x != 100 || y != 50 || camera.Zoom != 3 {
+		t.Errorf("got center (%v, %v) zoom %v, want (100, 50) zoom 3", x, y, camera.Zoom)
+	}
+
+	// zoom is kept, zero duration moves immediately
+	camera.MoveTo(10, 20, 0, 0, "")
+	if x, y := camera.Center(); x != 10 || y != 20 || camera.Zoom != 3 {
+		t.Errorf("got center (%v, %v) zoom %v, want (10, 20) zoom 3", x, y, camera.Zoom)
+	}
+
+	camera.MoveTo(10, 20, 1000, 0, "")
+	if camera.Zoom != maxZoom {
+		t.Errorf("got zoom %v, want clamped to %v", camera.Zoom, maxZoom)
+	}
+
+	if err := camera.MoveTo(0, 0, 0, 1, "bounce"); err == nil {
+		t.Error("expected error for unknown easing")
+	}
+	if err := camera.MoveTo(0, 0, 0, -1, ""); err == nil {
+		t.Error("expected error for negative duration")
+	}
+
+	// translation stops the transition
+	camera.MoveTo(100, 100, 0, 1, "")
+	camera.Translate(5, 5)
+	camera.Update(1, screen)
+	if x, y := camera.Center(); x != 5 || y != 5 {
+		t.Errorf("got center (%v, %v), want (5, 5) after translation", x, y)
+	}
+}
+
+func TestCamera2DFollow(t *testing.T) {
+	screen := &hgl.Screen{Width: 100, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Follow(100, 0, 0)
+
+	prev := float32(0)
+	for i := 0; i < 60; i++ {
+		camera.Update(1.0/60, screen)
+		x, _ := camera.Center()
+		if x <= prev || x > 100 {
+			t.Fatalf("frame %d: got x %v, want approaching 100 from %v", i, x, prev)
+		}
+		prev = x
+	}
+	if !hmath.CloseTo(prev, 100, 1) {
+		t.Errorf("got x %v after a second, want close to 100", prev)
+	}
+
+	// translation keeps following
+	camera.TranslateBy(-50, 0)
+	camera.Update(1.0/60, screen)
+	if x, _ := camera.Center(); x <= prev-50 {
+		t.Errorf("got x %v, want following after translation from %v", x, prev-50)
+	}
+	prev, _ = camera.Center()
+
+	// dragging takes over
+	camera.Drag(-50, 0)
+	camera.Update(1.0/60, screen)
+	if x, _ := camera.Center(); !hmath.CloseTo(x, prev-50, 0.001) {
+		t.Errorf("got x %v, want %v after drag", x, prev-50)
+	}
+
+	if err := camera.Follow(0, 0, -1); err == nil {
+		t.Error("expected error for negative speed")
+	}
+}
+
+func TestCamera2DInertia(t *testing.T) {
+	screen := &hgl.Screen{Width: 100, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Translate(0, 0)
+
+	for i := 0; i < 10; i++ {
+		camera.Drag(1, 0)
+		camera.Update(0.1, screen)
+	}
+	// nothing moves while dragging without movement
+	x0, _ := camera.Center()
+	camera.Update(0.1, screen)
+	if x, _ := camera.Center(); x != x0 {
+		t.Errorf("got x %v, want %v while dragging", x, x0)
+	}
+
+	camera.Drag(1, 0)
+	camera.Update(0.1, screen)
+	camera.ReleaseDrag()
+	x1, _ := camera.Center()
+	camera.Update(0.1, screen)
+	x2, _ := camera.Center()
+	if x2 <= x1 {
+		t.Errorf("got x %v, want camera gliding from %v after release", x2, x1)
+	}
+
+	for i := 0; i < 100; i++ {
+		camera.Update(0.1, screen)
+	}
+	x3, _ := camera.Center()
+	camera.Update(0.1, screen)
+	if x, _ := camera.Center(); x != x3 {
+		t.Errorf("got x %v, want inertia stopped at %v", x, x3)
+	}
+}
+
+func TestCamera2DBounds(t *testing.T) {
+	screen := &hgl.Screen{Width: 100, Height: 50, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Bounds = &Rect{MinX: 0, MinY: 0, MaxX: 200, MaxY: 30}
+
+	camera.Translate(-100, 10)
+	camera.Update(0, screen)
+	// x is clamped to half of the view, map lower than the view is centered
+	if x, y := camera.Center(); x != 50 || y != 15 {
+		t.Errorf("got center (%v, %v), want (50, 15)", x, y)
+	}
+
+	camera.SetZoom(2)
+	camera.Translate(190, 15)
+	camera.Update(0, screen)
+	if x, _ := camera.Center(); x != 175 {
+		t.Errorf("got x %v, want 175 at zoom 2", x)
+	}
+
+	rect := camera.VisibleRect(screen)
+	if rect.MaxX > 200.001 {
+		t.Errorf("got visible rect %v, want within bounds", rect)
+	}
+}
+
+func TestCamera2DTranslateByNoInertia(t *testing.T) {
+	screen := &hgl.Screen{Width: 100, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.TranslateBy(10, 0)
+	camera.Update(0.1, screen)
+	camera.ReleaseDrag()
+	camera.Update(0.1, screen)
+
+	if x, _ := camera.Center(); x != 10 {
+		t.Errorf("got x %v, want 10 without inertia", x)
+	}
+}
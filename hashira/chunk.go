@@ -0,0 +1,122 @@
+package hashira
+
+import (
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+// ChunkSize is the width and height of a chunk in tiles, chunks at map edges can be smaller.
+const ChunkSize = 32
+
+// Chunk is a part of the map with its own mesh so it can be uploaded to GPU
+// and updated independently of the rest of the map.
+type Chunk struct {
+	// position of the bottom left tile in mesh coordinates (y grows up)
+	X      int
+	Y      int
+	Width  int
+	Height int
+
+	// Mesh has static vertices and UVs for every layer (submesh order).
+	Mesh *hgl.Mesh
+	// tiles of every layer (submesh order) changed since ClearDirty
+	dirty []tileRect
+}
+
+// tileRect is a rectangle [x0, x1) x [y0, y1) of tiles relative to the chunk (y grows up).
+type tileRect struct {
+	x0, y0, x1, y1 int
+}
+
+func (r tileRect) empty() bool {
+	return r.x0 >= r.x1 || r.y0 >= r.y1
+}
+
+// add grows the rectangle to include the tile.
+func (r *tileRect) add(x, y int) {
+	if r.empty() {
+		*r = tileRect{x, y, x + 1, y + 1}
+		return
+	}
+	if x < r.x0 {
+		r.x0 = x
+	}
+	if y < r.y0 {
+		r.y0 = y
+	}
+	if x >= r.x1 {
+		r.x1 = x + 1
+	}
+	if y >= r.y1 {
+		r.y1 = y + 1
+	}
+}
+
+func newChunk(x, y, width, height, tileWidth, tileHeight int) *Chunk {
+	c := &Chunk{
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+		Mesh: &hgl.Mesh{
+			SubMeshes: make([]*hgl.SubMesh, 0),
+		},
+	}
+	c.Mesh.Vertices = hgl.NewVertexBuffer3f(c.VerticesNeeded())
+
+	tw := float32(tileWidth)
+	th := float32(tileHeight)
+	for cy := 0; cy < height; cy++ {
+		for cx := 0; cx < width; cx++ {
+			x := float32(x + cx)
+			y := float32(y + cy)
+			c.Mesh.Vertices.SetQuad(c.Quad(cx, cy), x*tw, y*th, (x+1)*tw, (y+1)*th, 0)
+		}
+	}
+
+	return c
+}
+
+// Quad returns index of quad at position relative to the chunk (y grows up).
+func (c *Chunk) Quad(x, y int) int {
+	return y*c.Width + x
+}
+
+func (c *Chunk) Quads() int {
+	return c.Width * c.Height
+}
+
+func (c *Chunk) VerticesNeeded() int {
+	return c.Quads() * hgl.VerticesPerQuad
+}
+
+// DirtyTiles returns rectangle [x0, x1) x [y0, y1) relative to the chunk (y grows up)
+// covering tiles of given submesh changed since ClearDirty, tracked even when UVs are not built.
+func (c *Chunk) DirtyTiles(subMesh int) (x0, y0, x1, y1 int, ok bool) {
+	r := c.dirty[subMesh]
+	if r.empty() {
+		return 0, 0, 0, 0, false
+	}
+	return r.x0, r.y0, r.x1, r.y1, true
+}
+
+func (c *Chunk) markDirty(subMesh int, quad int) {
+	c.dirty[subMesh].add(quad%c.Width, quad/c.Width)
+}
+
+// ClearDirty should be called once the submesh (or tiles it represents) are uploaded.
+func (c *Chunk) ClearDirty(subMesh int) {
+	c.dirty[subMesh] = tileRect{}
+	c.Mesh.SubMeshes[subMesh].UVs.Data().ClearDirty()
+	c.Mesh.SubMeshes[subMesh].Indices.Data().ClearDirty()
+}
+
+func (c *Chunk) addSubMesh(z float32) {
+	c.Mesh.SubMeshes = append(c.Mesh.SubMeshes, &hgl.SubMesh{
+		Model:   hmath.TranslationMatrix(hmath.Vertex{0, 0, z}),
+		UVs:     hgl.NewVertexBuffer2f(c.VerticesNeeded()),
+		Indices: hgl.NewQuadIndexBuffer(c.Quads()),
+	})
+	// new layer has to be uploaded whole
+	c.dirty = append(c.dirty, tileRect{0, 0, c.Width, c.Height})
+}
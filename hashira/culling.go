@@ -0,0 +1,95 @@
+package hashira
+
+import (
+	"math"
+
+	"github.com/qbart/hashira/hgl"
+)
+
+// Rect is an axis aligned rectangle in world space.
+type Rect struct {
+	MinX float32
+	MinY float32
+	MaxX float32
+	MaxY float32
+}
+
+func (r Rect) Intersects(other Rect) bool {
+	return r.MinX < other.MaxX && other.MinX < r.MaxX &&
+		r.MinY < other.MaxY && other.MinY < r.MaxY
+}
+
+// VisibleRect returns world rectangle covered by the camera viewport.
+func (c *Camera2D) VisibleRect(screen *hgl.Screen) Rect {
+	x, y, w, h := c.Viewport(screen)
+	x0, y0 := c.ScreenToWorld(screen, float32(x), float32(y))
+	x1, y1 := c.ScreenToWorld(screen, float32(x+w), float32(y+h))
+	return Rect{
+		MinX: float32(math.Min(float64(x0), float64(x1))),
+		MinY: float32(math.Min(float64(y0), float64(y1))),
+		MaxX: float32(math.Max(float64(x0), float64(x1))),
+		MaxY: float32(math.Max(float64(y0), float64(y1))),
+	}
+}
+
+// VisibleChunk is a part of the chunk to draw, rows [FromRow, ToRow) are relative to the chunk.
+type VisibleChunk struct {
+	*Chunk
+	FromRow int
+	ToRow   int
+}
+
+// Quads returns visible range of quads, rows are contiguous in chunk mesh.
+func (v VisibleChunk) Quads() (from, to int) {
+	return v.FromRow * v.Width, v.ToRow * v.Width
+}
+
+// VisibleTiles returns range of tiles [x0, x1) x [y0, y1) in mesh coordinates (y grows up)
+// intersecting world rectangle, ok is false when nothing is visible.
+func (m *Map) VisibleTiles(rect Rect) (x0, y0, x1, y1 int, ok bool) {
+	tw := float64(m.TileWidth)
+	th := float64(m.TileHeight)
+	x0 = clampTile(math.Floor(float64(rect.MinX)/tw), m.Width)
+	x1 = clampTile(math.Ceil(float64(rect.MaxX)/tw), m.Width)
+	y0 = clampTile(math.Floor(float64(rect.MinY)/th), m.Height)
+	y1 = clampTile(math.Ceil(float64(rect.MaxY)/th), m.Height)
+	return x0, y0, x1, y1, x0 < x1 && y0 < y1
+}
+
+// VisibleChunks returns chunks intersecting world rectangle with their visible rows.
+func (m *Map) VisibleChunks(rect Rect) []VisibleChunk {
+	x0, y0, x1, y1, ok := m.VisibleTiles(rect)
+	if !ok {
+		return nil
+	}
+
+	visible := make([]VisibleChunk, 0)
+	for cy := y0 / ChunkSize; cy <= (y1-1)/ChunkSize; cy++ {
+		for cx := x0 / ChunkSize; cx <= (x1-1)/ChunkSize; cx++ {
+			c := m.Chunks[cy*m.ChunksX+cx]
+			v := VisibleChunk{
+				Chunk:   c,
+				FromRow: y0 - c.Y,
+				ToRow:   y1 - c.Y,
+			}
+			if v.FromRow < 0 {
+				v.FromRow = 0
+			}
+			if v.ToRow > c.Height {
+				v.ToRow = c.Height
+			}
+			visible = append(visible, v)
+		}
+	}
+	return visible
+}
+
+func clampTile(value float64, size int) int {
+	if value < 0 {
+		return 0
+	}
+	if value > float64(size) {
+		return size
+	}
+	return int(value)
+}
@@ -0,0 +1,56 @@
+package hashira
+
+import (
+	"testing"
+
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+func TestCamera2DVisibleRect(t *testing.T) {
+	screen := &hgl.Screen{Width: 200, Height: 100, DevicePixelRatio: 1}
+	camera := NewCamera2D()
+	camera.Translate(50, 40)
+	camera.SetZoom(2)
+
+	got := camera.VisibleRect(screen)
+	want := Rect{MinX: 0, MinY: 15, MaxX: 100, MaxY: 65}
+	if !hmath.CloseTo(got.MinX, want.MinX, 0.01) || !hmath.CloseTo(got.MinY, want.MinY, 0.01) ||
+		!hmath.CloseTo(got.MaxX, want.MaxX, 0.01) || !hmath.CloseTo(got.MaxY, want.MaxY, 0.01) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMapVisibleChunks(t *testing.T) {
+	m := newMap(ChunkSize*2+16, ChunkSize*2+16, 10, 10)
+
+	visible := m.VisibleChunks(Rect{MinX: 310, MinY: 5, MaxX: 330, MaxY: 25})
+	if len(visible) != 2 {
+		t.Fatalf("got %d visible chunks, want 2", len(visible))
+	}
+	for i, want := range []*Chunk{m.Chunks[0], m.Chunks[1]} {
+		v := visible[i]
+		if v.Chunk != want {
+			t.Errorf("visible %d: got chunk at (%d, %d), want (%d, %d)", i, v.X, v.Y, want.X, want.Y)
+		}
+		if v.FromRow != 0 || v.ToRow != 3 {
+			t.Errorf("visible %d: got rows [%d, %d), want [0, 3)", i, v.FromRow, v.ToRow)
+		}
+	}
+	if from, to := visible[1].Quads(); from != 0 || to != 3*ChunkSize {
+		t.Errorf("got quads [%d, %d), want [0, %d)", from, to, 3*ChunkSize)
+	}
+
+	// rows start inside of the chunk
+	visible = m.VisibleChunks(Rect{MinX: 0, MinY: 335, MaxX: 10, MaxY: 345})
+	if len(visible) != 1 || visible[0].Chunk != m.Chunks[3] || visible[0].FromRow != 1 || visible[0].ToRow != 3 {
+		t.Errorf("got %+v, want rows [1, 3) of chunk 3", visible)
+	}
+
+	if got := len(m.VisibleChunks(Rect{MinX: -100, MinY: -100, MaxX: 2000, MaxY: 2000})); got != 9 {
+		t.Errorf("got %d visible chunks, want all 9", got)
+	}
+	if got := m.VisibleChunks(Rect{MinX: -100, MinY: 0, MaxX: -1, MaxY: 100}); got != nil {
+		t.Errorf("got %d visible chunks, want none outside of the map", len(got))
+	}
+}
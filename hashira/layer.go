@@ -1,8 +1,22 @@
 package hashira
 
+import "github.com/qbart/hashira/ds"
+
 type Layer struct {
-	Z    float32
-	Data [][]int
+	Z       float32
+	Data    [][]int
+	Tileset string
+
+	// animated tiles by cell index (y * width + x)
+	AnimatedTiles *ds.HashMap[int, *AnimatedTile]
+}
+
+// TilesetName returns name of the tileset the layer samples from.
+func (l *Layer) TilesetName() string {
+	if l.Tileset == "" {
+		return DefaultTileset
+	}
+	return l.Tileset
 }
 
 func (l *Layer) Tile(x int, y int) int {
@@ -12,3 +26,24 @@ func (l *Layer) Tile(x int, y int) int {
 func (l *Layer) SetTile(x int, y int, tile int) {
 	l.Data[y][x] = tile
 }
+
+// VisibleTile returns the tile that should be rendered at given position,
+// which is the current animation frame for animated tiles (flip flags are kept).
+func (l *Layer) VisibleTile(x int, y int) int {
+	if a, ok := l.animatedTile(x, y); ok {
+		return a.Tile() | TileFlags(l.Tile(x, y))
+	}
+	return l.Tile(x, y)
+}
+
+func (l *Layer) animatedTile(x int, y int) (*AnimatedTile, bool) {
+	key := l.cellIndex(x, y)
+	if !l.AnimatedTiles.Has(key) {
+		return nil, false
+	}
+	return l.AnimatedTiles.Get(key), true
+}
+
+func (l *Layer) cellIndex(x int, y int) int {
+	return y*len(l.Data[y]) + x
+}
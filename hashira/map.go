@@ -1,8 +1,9 @@
 package hashira
 
 import (
+	"math"
+
 	"github.com/qbart/hashira/ds"
-	"github.com/qbart/hashira/hgl"
 )
 
 type Map struct {
@@ -11,22 +12,96 @@ type Map struct {
 	TileWidth  int
 	TileHeight int
 
-	Layers             *ds.HashMap[string, *Layer]
-	Mesh               *hgl.Mesh
+	Layers *ds.HashMap[string, *Layer]
+	// Chunks in row major order starting from the bottom left corner of the map.
+	Chunks             []*Chunk
+	ChunksX            int
+	ChunksY            int
 	SubMeshIndexByName *ds.HashMap[string, int]
+	// layer names in submesh order
+	SubMeshLayerNames []string
+}
+
+func newMap(width int, height int, tileWidth int, tileHeight int) *Map {
+	m := &Map{
+		Width:              width,
+		Height:             height,
+		TileWidth:          tileWidth,
+		TileHeight:         tileHeight,
+		Layers:             ds.NewHashMap[string, *Layer](),
+		ChunksX:            (width + ChunkSize - 1) / ChunkSize,
+		ChunksY:            (height + ChunkSize - 1) / ChunkSize,
+		SubMeshIndexByName: ds.NewHashMap[string, int](),
+		SubMeshLayerNames:  make([]string, 0),
+	}
+	m.Chunks = make([]*Chunk, 0, m.ChunksX*m.ChunksY)
+	for cy := 0; cy < m.ChunksY; cy++ {
+		for cx := 0; cx < m.ChunksX; cx++ {
+			x := cx * ChunkSize
+			y := cy * ChunkSize
+			m.Chunks = append(m.Chunks, newChunk(x, y, chunkExtent(width-x), chunkExtent(height-y), tileWidth, tileHeight))
+		}
+	}
+	return m
+}
+
+// chunkExtent limits size left to the map edge to the chunk size.
+func chunkExtent(left int) int {
+	if left > ChunkSize {
+		return ChunkSize
+	}
+	return left
 }
 
-func (m *Map) TileIndex(x, y int) int {
+// ChunkTile returns chunk and quad index within the chunk of the tile
+// at given position (top down order as in Layer.Data).
+func (m *Map) ChunkTile(x, y int) (*Chunk, int) {
+	// flip y for natural top down order
+	return m.MeshTile(x, m.Height-y-1)
+}
+
+// MeshTile returns chunk and quad index within the chunk of the tile
+// at given mesh position (y grows up).
+func (m *Map) MeshTile(x, y int) (*Chunk, int) {
+	c := m.Chunks[(y/ChunkSize)*m.ChunksX+x/ChunkSize]
+	return c, c.Quad(x-c.X, y-c.Y)
+}
+
+// WorldToTile converts world position to tile coordinates (top down order as in Layer.Data).
+// ok is false when position is outside of the map.
+func (m *Map) WorldToTile(wx, wy float32) (x, y int, ok bool) {
+	x = int(math.Floor(float64(wx) / float64(m.TileWidth)))
+	y = int(math.Floor(float64(wy) / float64(m.TileHeight)))
 	// flip y for natural top down order
 	y = m.Height - y - 1
-	return y*m.Width + x
+	ok = x >= 0 && x < m.Width && y >= 0 && y < m.Height
+	return x, y, ok
 }
 
 func (m *Map) Center() (x, y float32) {
 	return float32(m.Width) / 2, float32(m.Height) / 2
 }
 
-func (m *Map) VerticesNeeded() int {
-	// map size * 6 vertices per tile (we could share vertices between tiles but this is easier)
-	return m.Width * m.Height * 6
+// Rect returns world rectangle covered by the map.
+func (m *Map) Rect() Rect {
+	return Rect{
+		MaxX: float32(m.Width * m.TileWidth),
+		MaxY: float32(m.Height * m.TileHeight),
+	}
+}
+
+// DirtyRect returns rectangle [x0, x1) x [y0, y1) of layer data (top down order) covering
+// tiles of the chunk changed since ClearDirty.
+func (m *Map) DirtyRect(c *Chunk, subMesh int) (x0, y0, x1, y1 int, ok bool) {
+	cx0, cy0, cx1, cy1, ok := c.DirtyTiles(subMesh)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	// flip y for natural top down order
+	return c.X + cx0, m.Height - c.Y - cy1, c.X + cx1, m.Height - c.Y - cy0, true
+}
+
+// SubMeshLayer returns layer rendered by submesh at given index.
+func (m *Map) SubMeshLayer(index int) *Layer {
+	return m.Layers.Get(m.SubMeshLayerNames[index])
 }
@@ -0,0 +1,82 @@
+package hashira
+
+import (
+	"testing"
+
+	"github.com/qbart/hashira/hgl"
+)
+
+func TestMapWorldToTile(t *testing.T) {
+	m := &Map{Width: 4, Height: 3, TileWidth: 16, TileHeight: 8}
+
+	tests := []struct {
+		wx, wy float32
+		x, y   int
+		ok     bool
+	}{
+		{0, 0, 0, 2, true},
+		{15.9, 7.9, 0, 2, true},
+		{16, 8, 1, 1, true},
+		{63.9, 23.9, 3, 0, true},
+		{64, 0, 4, 2, false},
+		{0, 24, 0, -1, false},
+		{-0.1, 0, -1, 2, false},
+	}
+
+	for _, tt := range tests {
+		x, y, ok := m.WorldToTile(tt.wx, tt.wy)
+		if x != tt.x || y != tt.y || ok != tt.ok {
+			t.Errorf("world (%v, %v): got (%d, %d, %v), want (%d, %d, %v)", tt.wx, tt.wy, x, y, ok, tt.x, tt.y, tt.ok)
+		}
+	}
+}
+
+func TestMapChunkTile(t *testing.T) {
+	m := newMap(4, 3, 16, 16)
+
+	c, quad := m.ChunkTile(0, 0)
+	if c != m.Chunks[0] || quad != 8 {
+		t.Errorf("got quad %d, want top left tile to be first in the last mesh row (8)", quad)
+	}
+	c, quad = m.ChunkTile(3, 2)
+	if c != m.Chunks[0] || quad != 3 {
+		t.Errorf("got quad %d, want bottom right tile to be last in the first mesh row (3)", quad)
+	}
+}
+
+func TestMapChunks(t *testing.T) {
+	m := newMap(ChunkSize+8, ChunkSize+3, 16, 16)
+
+	if len(m.Chunks) != 4 || m.ChunksX != 2 || m.ChunksY != 2 {
+		t.Fatalf("got %d chunks (%dx%d), want 4 (2x2)", len(m.Chunks), m.ChunksX, m.ChunksY)
+	}
+	sizes := [][4]int{
+		{0, 0, ChunkSize, ChunkSize},
+		{ChunkSize, 0, 8, ChunkSize},
+		{0, ChunkSize, ChunkSize, 3},
+		{ChunkSize, ChunkSize, 8, 3},
+	}
+	for i, want := range sizes {
+		c := m.Chunks[i]
+		if got := [4]int{c.X, c.Y, c.Width, c.Height}; got != want {
+			t.Errorf("chunk %d: got %v, want %v", i, got, want)
+		}
+		if got := c.Mesh.Vertices.Len(); got != c.Width*c.Height*hgl.VerticesPerQuad {
+			t.Errorf("chunk %d: got %d vertices, want %d", i, got, c.Width*c.Height*hgl.VerticesPerQuad)
+		}
+	}
+
+	// top row of the data is in the top right chunk
+	c, quad := m.ChunkTile(ChunkSize+1, 0)
+	if c != m.Chunks[3] {
+		t.Fatalf("got chunk at (%d, %d), want top right chunk", c.X, c.Y)
+	}
+	if quad != 2*8+1 {
+		t.Errorf("got quad %d, want %d", quad, 2*8+1)
+	}
+	// vertices are in world space
+	x, y, _ := c.Mesh.Vertices.At(quad * hgl.VerticesPerQuad)
+	if x != float32(ChunkSize+1)*16 || y != float32(ChunkSize+2)*16 {
+		t.Errorf("got vertex (%v, %v), want (%v, %v)", x, y, (ChunkSize+1)*16, (ChunkSize+2)*16)
+	}
+}
@@ -6,8 +6,9 @@ import (
 
 func New() *World {
 	return &World{
-		Maps:      ds.NewHashMap[string, *Map](),
-		Resources: &Resources{},
-		synced:    true,
+		Maps:       ds.NewHashMap[string, *Map](),
+		Animations: ds.NewHashMap[int, *Animation](),
+		Resources:  NewResources(),
+		synced:     true,
 	}
 }
@@ -0,0 +1,46 @@
+package hashira
+
+import "sort"
+
+type TilePick struct {
+	Map   string
+	Layer string
+	X     int
+	Y     int
+	Tile  int
+}
+
+// PickTile finds the top most non empty layer tile at world position,
+// top most layer is picked when all tiles are empty.
+// Maps are checked in name order.
+func (w *World) PickTile(wx, wy float32) (TilePick, bool) {
+	names := w.Maps.Keys()
+	sort.Strings(names)
+	for _, name := range names {
+		m := w.Maps.Get(name)
+		x, y, ok := m.WorldToTile(wx, wy)
+		if !ok || len(m.SubMeshLayerNames) == 0 {
+			continue
+		}
+
+		layers := append([]string(nil), m.SubMeshLayerNames...)
+		sort.SliceStable(layers, func(i, j int) bool {
+			return m.Layers.Get(layers[i]).Z > m.Layers.Get(layers[j]).Z
+		})
+		layerName := layers[0]
+		for _, l := range layers {
+			if m.Layers.Get(l).Tile(x, y) != EmptyTile {
+				layerName = l
+				break
+			}
+		}
+		return TilePick{
+			Map:   name,
+			Layer: layerName,
+			X:     x,
+			Y:     y,
+			Tile:  m.Layers.Get(layerName).Tile(x, y),
+		}, true
+	}
+	return TilePick{}, false
+}
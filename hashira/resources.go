@@ -1,33 +1,156 @@
 package hashira
 
 import (
+	"fmt"
+
+	"github.com/qbart/hashira/ds"
 	"github.com/qbart/hashira/hgl"
 )
 
+// DefaultTileset is used when tileset or layer does not specify a tileset name.
+const DefaultTileset = "tileset"
+
 type Resources struct {
-	Tileset *Tileset
-	Image   *hgl.Image
-	Texture hgl.Texture
+	Tilesets *ds.HashMap[string, *Tileset]
+	Images   *ds.HashMap[string, *hgl.Image]
+	// Layouts of tilesets known from imported maps and saved worlds,
+	// used when the tileset is loaded without a layout
+	Layouts *ds.HashMap[string, TilesetLayout]
+	// TextureOptions by image name, DefaultTextureOptions are used for the rest
+	TextureOptions        *ds.HashMap[string, hgl.TextureOptions]
+	DefaultTextureOptions hgl.TextureOptions
+}
+
+func NewResources() *Resources {
+	return &Resources{
+		Tilesets:       ds.NewHashMap[string, *Tileset](),
+		Images:         ds.NewHashMap[string, *hgl.Image](),
+		Layouts:        ds.NewHashMap[string, TilesetLayout](),
+		TextureOptions: ds.NewHashMap[string, hgl.TextureOptions](),
+	}
 }
 
-func (r *Resources) LoadTileset(data []byte) (*hgl.Image, error) {
+// SetTextureOptions sets sampling of the image, empty name changes defaults of all images.
+func (r *Resources) SetTextureOptions(name string, options hgl.TextureOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	if name == "" {
+		r.DefaultTextureOptions = options
+		return nil
+	}
+	r.TextureOptions.Set(name, options)
+	return nil
+}
+
+// GetTextureOptions returns sampling of the image.
+func (r *Resources) GetTextureOptions(name string) hgl.TextureOptions {
+	if r.TextureOptions.Has(name) {
+		return r.TextureOptions.Get(name)
+	}
+	return r.DefaultTextureOptions
+}
+
+// LoadTileset loads tileset image, zero layout falls back to the one set by SetTilesetLayout.
+func (r *Resources) LoadTileset(name string, data []byte, layout TilesetLayout) (*hgl.Image, error) {
+	if name == "" {
+		name = DefaultTileset
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
 	img, err := hgl.LoadImagePNGFromBytes(data)
 	if err != nil {
 		return nil, err
 	}
-	r.Image = img
-	r.Tileset = &Tileset{
-		Name:   "tileset",
-		Width:  img.Width,
-		Height: img.Height,
+	if layout == (TilesetLayout{}) && r.Layouts.Has(name) {
+		layout = r.Layouts.Get(name)
 	}
+	r.Images.Set(name, img)
+	r.Tilesets.Set(name, &Tileset{
+		Name:          name,
+		Width:         img.Width,
+		Height:        img.Height,
+		TilesetLayout: layout,
+	})
 	return img, nil
 }
 
-func (r *Resources) HasTileset() bool {
-	return r.Tileset != nil
+// SetTilesetLayout remembers layout of the tileset and applies it when the tileset is already loaded.
+func (r *Resources) SetTilesetLayout(name string, layout TilesetLayout) {
+	r.Layouts.Set(name, layout)
+	if t := r.GetTileset(name); t != nil && t.TilesetLayout != layout {
+		// replaced so renderers caching tilesets notice the change
+		updated := *t
+		updated.TilesetLayout = layout
+		r.Tilesets.Set(name, &updated)
+	}
+}
+
+func (r *Resources) HasTileset(name string) bool {
+	return r.Tilesets.Has(name)
+}
+
+// TextureName returns name of the image tileset samples from,
+// tilesets packed into an atlas share its image.
+func (r *Resources) TextureName(tileset string) string {
+	if t := r.GetTileset(tileset); t != nil {
+		return t.TextureName()
+	}
+	return tileset
+}
+
+// GetTileset returns nil when tileset is not loaded.
+func (r *Resources) GetTileset(name string) *Tileset {
+	return r.Tilesets.Get(name)
 }
 
-func (r *Resources) GetTileset() *Tileset {
-	return r.Tileset
+// PackAtlas packs tiles of loaded tilesets into a single image stored under name
+// and remaps the tilesets to sample from it (see Tileset.Regions).
+// Borders of tiles are extruded so tiles do not bleed into each other at fractional zooms.
+func (r *Resources) PackAtlas(name string, tileWidth, tileHeight, extrude int, tilesets ...string) (*hgl.Image, error) {
+	if name == "" {
+		return nil, fmt.Errorf("atlas name is required")
+	}
+	sources := make([]hgl.AtlasSource, 0, len(tilesets))
+	for _, tilesetName := range tilesets {
+		t := r.GetTileset(tilesetName)
+		if t == nil {
+			return nil, fmt.Errorf("tileset not loaded: %v", tilesetName)
+		}
+		img := r.Images.Get(t.TextureName())
+		if img == nil {
+			return nil, fmt.Errorf("image of tileset %q not loaded: %v", tilesetName, t.TextureName())
+		}
+		count := len(t.Regions)
+		if t.Regions == nil {
+			_, count = t.Grid(tileWidth, tileHeight)
+		}
+		tiles := make([]hgl.AtlasRect, count)
+		for i := range tiles {
+			rect, err := t.TileRect(i, tileWidth, tileHeight)
+			if err != nil {
+				return nil, err
+			}
+			tiles[i] = rect
+		}
+		sources = append(sources, hgl.AtlasSource{Name: tilesetName, Image: img, Tiles: tiles})
+	}
+
+	atlas, err := hgl.BuildAtlas(sources, extrude)
+	if err != nil {
+		return nil, fmt.Errorf("error packing atlas: %v", err)
+	}
+
+	r.Images.Set(name, atlas.Image)
+	for _, tilesetName := range tilesets {
+		// replaced so renderers caching tilesets notice the change
+		t := *r.GetTileset(tilesetName)
+		t.Width = atlas.Image.Width
+		t.Height = atlas.Image.Height
+		t.Texture = name
+		t.Regions = atlas.Tiles.Get(tilesetName)
+		r.Tilesets.Set(tilesetName, &t)
+	}
+	return atlas.Image, nil
 }
@@ -0,0 +1,134 @@
+package hashira
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+func newTestResources() *Resources {
+	r := NewResources()
+	for _, name := range []string{"terrain", "props"} {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+		r.Images.Set(name, &hgl.Image{Image: img, Width: 64, Height: 32})
+		r.Tilesets.Set(name, &Tileset{Name: name, Width: 64, Height: 32})
+	}
+	return r
+}
+
+func TestResourcesPackAtlas(t *testing.T) {
+	r := newTestResources()
+
+	img, err := r.PackAtlas("atlas", 16, 16, 1, "terrain", "props")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Images.Get("atlas") != img {
+		t.Error("atlas image should be stored in resources")
+	}
+	if got := r.TextureName("props"); got != "atlas" {
+		t.Errorf("got texture %q, want atlas", got)
+	}
+	if got := r.TextureName("missing"); got != "missing" {
+		t.Errorf("got texture %q of unknown tileset, want its name", got)
+	}
+
+	props := r.GetTileset("props")
+	if len(props.Regions) != 8 || props.Width != img.Width || props.Height != img.Height {
+		t.Fatalf("got %d regions of %dx%d texture", len(props.Regions), props.Width, props.Height)
+	}
+	region := props.Regions[5]
+	u0, v0, u1, v1, err := props.TextureUV(5, 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := float32(img.Width)
+	h := float32(img.Height)
+	got := []float32{u0 * w, v0 * h, u1 * w, v1 * h}
+	want := []float32{float32(region.X), float32(region.Y), float32(region.X + 16), float32(region.Y + 16)}
+	for i := range got {
+		if !hmath.CloseTo(got[i], want[i], 0.02) {
+			t.Errorf("got pixels %v, want %v", got, want)
+			break
+		}
+	}
+	if _, _, _, _, err := props.TextureUV(8, 16, 16); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestResourcesPackAtlasMissingTileset(t *testing.T) {
+	r := newTestResources()
+
+	if _, err := r.PackAtlas("atlas", 16, 16, 1, "terrain", "missing"); err == nil {
+		t.Error("expected error")
+	}
+	if r.Images.Has("atlas") || r.TextureName("terrain") != "terrain" {
+		t.Error("resources should not change on error")
+	}
+}
+
+func TestResourcesTextureOptions(t *testing.T) {
+	r := newTestResources()
+	linear := hgl.TextureOptions{Filter: hgl.FilterLinear, Mipmaps: true}
+
+	if err := r.SetTextureOptions("", linear); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetTextureOptions("props", hgl.TextureOptions{Wrap: hgl.WrapRepeat}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.GetTextureOptions("terrain"); got != linear {
+		t.Errorf("got %+v, want defaults %+v", got, linear)
+	}
+	if got := r.GetTextureOptions("props"); got.Wrap != hgl.WrapRepeat || got.Linear() {
+		t.Errorf("got %+v, want own options of props", got)
+	}
+	if err := r.SetTextureOptions("props", hgl.TextureOptions{Filter: "cubic"}); err == nil {
+		t.Error("expected unknown filter error")
+	}
+	if got := r.GetTextureOptions("props"); got.Wrap != hgl.WrapRepeat {
+		t.Errorf("invalid options should not be applied, got %+v", got)
+	}
+}
+
+func TestResourcesTilesetLayout(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 34, 16))); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResources()
+	layout := TilesetLayout{Margin: 1, Spacing: 0, Columns: 2, TileCount: 2}
+	r.SetTilesetLayout("terrain", layout)
+
+	if _, err := r.LoadTileset("terrain", buf.Bytes(), TilesetLayout{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.GetTileset("terrain").TilesetLayout; got != layout {
+		t.Errorf("got layout %+v, want remembered %+v", got, layout)
+	}
+
+	explicit := TilesetLayout{Columns: 1}
+	if _, err := r.LoadTileset("terrain", buf.Bytes(), explicit); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.GetTileset("terrain").TilesetLayout; got != explicit {
+		t.Errorf("got layout %+v, want explicit %+v", got, explicit)
+	}
+
+	loaded := r.GetTileset("terrain")
+	r.SetTilesetLayout("terrain", layout)
+	if got := r.GetTileset("terrain"); got == loaded || got.TilesetLayout != layout {
+		t.Error("loaded tileset should be replaced with the new layout")
+	}
+
+	if _, err := r.LoadTileset("terrain", buf.Bytes(), TilesetLayout{Spacing: -1}); err == nil {
+		t.Error("expected error for negative spacing")
+	}
+}
@@ -0,0 +1,229 @@
+package hashira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SaveVersion is the current version of the hashira world file format.
+// Bump it whenever WorldData changes in a non backward compatible way.
+// Version 2 adds tileset layouts.
+const SaveVersion = 2
+
+// Limits checked when loading a world, before any map is allocated.
+const (
+	// MaxMapSize is the maximum width or height of a map in tiles.
+	MaxMapSize = 1 << 14
+	// MaxMapCells is the maximum number of tiles of a map layer.
+	MaxMapCells = 1 << 22
+)
+
+type SaveFormat string
+
+const (
+	SaveFormatJSON   SaveFormat = "json"
+	SaveFormatBinary SaveFormat = "binary"
+)
+
+type WorldData struct {
+	Version    int          `json:"version"`
+	Maps       []*MapData   `json:"maps"`
+	Animations []*Animation `json:"animations"`
+	// tilesets with non zero layout
+	Tilesets []*TilesetData `json:"tilesets,omitempty"`
+}
+
+type TilesetData struct {
+	Name string `json:"name"`
+	TilesetLayout
+}
+
+type MapData struct {
+	Name       string       `json:"name"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	TileWidth  int          `json:"tile_width"`
+	TileHeight int          `json:"tile_height"`
+	Layers     []*LayerData `json:"layers"`
+}
+
+// Validate checks the map size against the loading limits.
+func (m *MapData) Validate() error {
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return fmt.Errorf("map %q: invalid tile size %dx%d", m.Name, m.TileWidth, m.TileHeight)
+	}
+	if m.Width < 0 || m.Height < 0 || m.Width > MaxMapSize || m.Height > MaxMapSize {
+		return fmt.Errorf("map %q: invalid size %dx%d, maximum is %d", m.Name, m.Width, m.Height, MaxMapSize)
+	}
+	if m.Width*m.Height > MaxMapCells {
+		return fmt.Errorf("map %q: %d tiles exceed the maximum of %d", m.Name, m.Width*m.Height, MaxMapCells)
+	}
+	return nil
+}
+
+type LayerData struct {
+	Name    string  `json:"name"`
+	Z       float32 `json:"z"`
+	Tileset string  `json:"tileset"`
+	Data    [][]int `json:"data"`
+}
+
+// Save serializes all maps, layers, animations and tileset layouts.
+// Tileset images are not included, layers only keep tileset names.
+func (w *World) Save(format SaveFormat) ([]byte, error) {
+	data := w.Export()
+	switch format {
+	case SaveFormatJSON:
+		return json.Marshal(data)
+	case SaveFormatBinary:
+		return encodeBinary(data)
+	default:
+		return nil, fmt.Errorf("unknown save format: %v", format)
+	}
+}
+
+// Load replaces all maps and animations with the ones from saved data.
+// Format is detected automatically.
+func (w *World) Load(b []byte) error {
+	var data *WorldData
+	if bytes.HasPrefix(b, binaryMagic) {
+		d, err := decodeBinary(b)
+		if err != nil {
+			return err
+		}
+		data = d
+	} else {
+		data = &WorldData{}
+		if err := json.Unmarshal(b, data); err != nil {
+			return fmt.Errorf("error decoding world: %v", err)
+		}
+	}
+	return w.Import(data)
+}
+
+// Export returns snapshot of the world.
+// Maps are sorted by name and layers are kept in the order they were added.
+func (w *World) Export() *WorldData {
+	data := &WorldData{
+		Version:    SaveVersion,
+		Maps:       make([]*MapData, 0, w.Maps.Len()),
+		Animations: make([]*Animation, 0, w.Animations.Len()),
+	}
+
+	names := w.Maps.Keys()
+	sort.Strings(names)
+	for _, name := range names {
+		m := w.Maps.Get(name)
+		md := &MapData{
+			Name:       name,
+			Width:      m.Width,
+			Height:     m.Height,
+			TileWidth:  m.TileWidth,
+			TileHeight: m.TileHeight,
+			Layers:     make([]*LayerData, 0, len(m.SubMeshLayerNames)),
+		}
+		for _, layerName := range m.SubMeshLayerNames {
+			layer := m.Layers.Get(layerName)
+			ld := &LayerData{
+				Name:    layerName,
+				Z:       layer.Z,
+				Tileset: layer.Tileset,
+				Data:    make([][]int, len(layer.Data)),
+			}
+			for y, row := range layer.Data {
+				ld.Data[y] = append([]int(nil), row...)
+			}
+			md.Layers = append(md.Layers, ld)
+		}
+		data.Maps = append(data.Maps, md)
+	}
+
+	bases := w.Animations.Keys()
+	sort.Ints(bases)
+	for _, base := range bases {
+		a := w.Animations.Get(base)
+		data.Animations = append(data.Animations, &Animation{
+			Frames: append([]int(nil), a.Frames...),
+			Delay:  a.Delay,
+		})
+	}
+
+	// layouts of loaded tilesets win over remembered ones
+	layouts := make(map[string]TilesetLayout)
+	w.Resources.Layouts.ForEach(func(name string, layout TilesetLayout) {
+		layouts[name] = layout
+	})
+	w.Resources.Tilesets.ForEach(func(name string, t *Tileset) {
+		layouts[name] = t.TilesetLayout
+	})
+	tilesets := make([]string, 0, len(layouts))
+	for name, layout := range layouts {
+		if layout != (TilesetLayout{}) {
+			tilesets = append(tilesets, name)
+		}
+	}
+	sort.Strings(tilesets)
+	for _, name := range tilesets {
+		data.Tilesets = append(data.Tilesets, &TilesetData{Name: name, TilesetLayout: layouts[name]})
+	}
+
+	return data
+}
+
+// Import replaces all maps and animations with the snapshot.
+func (w *World) Import(data *WorldData) error {
+	if data.Version < 1 || data.Version > SaveVersion {
+		return fmt.Errorf("unsupported world version: %v", data.Version)
+	}
+	for _, t := range data.Tilesets {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("tileset %q: %v", t.Name, err)
+		}
+	}
+	for _, a := range data.Animations {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, md := range data.Maps {
+		if err := md.Validate(); err != nil {
+			return err
+		}
+		for _, ld := range md.Layers {
+			if len(ld.Data) != md.Height {
+				return fmt.Errorf("map %q layer %q: expected %d rows, got %d", md.Name, ld.Name, md.Height, len(ld.Data))
+			}
+			for y, row := range ld.Data {
+				if len(row) != md.Width {
+					return fmt.Errorf("map %q layer %q: expected %d tiles in row %d, got %d", md.Name, ld.Name, md.Width, y, len(row))
+				}
+			}
+		}
+	}
+
+	w.Maps.Clear()
+	w.Animations.Clear()
+
+	for _, t := range data.Tilesets {
+		w.Resources.SetTilesetLayout(t.Name, t.TilesetLayout)
+	}
+
+	for _, a := range data.Animations {
+		w.Animations.Set(a.BaseTile(), &Animation{
+			Frames: append([]int(nil), a.Frames...),
+			Delay:  a.Delay,
+		})
+	}
+	for _, md := range data.Maps {
+		w.AddMap(md.Name, md.Width, md.Height, md.TileWidth, md.TileHeight)
+		for _, ld := range md.Layers {
+			w.AddLayer(md.Name, ld.Name, ld.Z, ld.Tileset)
+			w.AddLayerData(md.Name, ld.Name, ld.Data)
+		}
+	}
+	w.Resync()
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package hashira
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Binary layout (little endian, varints as in encoding/binary):
+//
+//	magic "HSHR"
+//	uint16 version
+//	uvarint maps count
+//	  string name, uvarint width, height, tile width, tile height
+//	  uvarint layers count
+//	    string name, float32 z, string tileset
+//	    varint tile * width * height (rows top to bottom)
+//	uvarint animations count
+//	  uvarint frames count, varint frame * count, float32 delay
+//	uvarint tilesets count (version 2)
+//	  string name, uvarint margin, spacing, columns, tile count
+//
+// Strings are stored as uvarint length followed by bytes.
+var binaryMagic = []byte("HSHR")
+
+func encodeBinary(data *WorldData) ([]byte, error) {
+	e := &binaryEncoder{}
+	e.buf.Write(binaryMagic)
+	e.uint16(uint16(data.Version))
+
+	e.uvarint(len(data.Maps))
+	for _, m := range data.Maps {
+		e.string(m.Name)
+		e.uvarint(m.Width)
+		e.uvarint(m.Height)
+		e.uvarint(m.TileWidth)
+		e.uvarint(m.TileHeight)
+		e.uvarint(len(m.Layers))
+		for _, l := range m.Layers {
+			e.string(l.Name)
+			e.float32(l.Z)
+			e.string(l.Tileset)
+			if len(l.Data) != m.Height {
+				return nil, fmt.Errorf("map %q layer %q: expected %d rows, got %d", m.Name, l.Name, m.Height, len(l.Data))
+			}
+			for y, row := range l.Data {
+				if len(row) != m.Width {
+					return nil, fmt.Errorf("map %q layer %q: expected %d tiles in row %d, got %d", m.Name, l.Name, m.Width, y, len(row))
+				}
+				for _, tile := range row {
+					e.varint(tile)
+				}
+			}
+		}
+	}
+
+	e.uvarint(len(data.Animations))
+	for _, a := range data.Animations {
+		e.uvarint(len(a.Frames))
+		for _, frame := range a.Frames {
+			e.varint(frame)
+		}
+		e.float32(a.Delay)
+	}
+
+	e.uvarint(len(data.Tilesets))
+	for _, t := range data.Tilesets {
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("tileset %q: %v", t.Name, err)
+		}
+		e.string(t.Name)
+		e.uvarint(t.Margin)
+		e.uvarint(t.Spacing)
+		e.uvarint(t.Columns)
+		e.uvarint(t.TileCount)
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+func decodeBinary(b []byte) (*WorldData, error) {
+	d := &binaryDecoder{r: bytes.NewReader(b[len(binaryMagic):])}
+	data := &WorldData{
+		Version: int(d.uint16()),
+	}
+	if d.err == nil && (data.Version < 1 || data.Version > SaveVersion) {
+		return nil, fmt.Errorf("unsupported world version: %v", data.Version)
+	}
+
+	mapsCount := d.uvarint()
+	for i := 0; i < mapsCount && d.err == nil; i++ {
+		m := &MapData{
+			Name:       d.string(),
+			Width:      d.uvarint(),
+			Height:     d.uvarint(),
+			TileWidth:  d.uvarint(),
+			TileHeight: d.uvarint(),
+		}
+		if d.err == nil {
+			if err := m.Validate(); err != nil {
+				return nil, err
+			}
+		}
+		layersCount := d.uvarint()
+		for j := 0; j < layersCount && d.err == nil; j++ {
+			l := &LayerData{
+				Name:    d.string(),
+				Z:       d.float32(),
+				Tileset: d.string(),
+			}
+			for y := 0; y < m.Height && d.err == nil; y++ {
+				row := make([]int, 0)
+				for x := 0; x < m.Width && d.err == nil; x++ {
+					row = append(row, d.varint())
+				}
+				l.Data = append(l.Data, row)
+			}
+			m.Layers = append(m.Layers, l)
+		}
+		data.Maps = append(data.Maps, m)
+	}
+
+	animationsCount := d.uvarint()
+	for i := 0; i < animationsCount && d.err == nil; i++ {
+		a := &Animation{}
+		framesCount := d.uvarint()
+		for j := 0; j < framesCount && d.err == nil; j++ {
+			a.Frames = append(a.Frames, d.varint())
+		}
+		a.Delay = d.float32()
+		data.Animations = append(data.Animations, a)
+	}
+
+	if data.Version >= 2 {
+		tilesetsCount := d.uvarint()
+		for i := 0; i < tilesetsCount && d.err == nil; i++ {
+			t := &TilesetData{Name: d.string()}
+			t.Margin = d.uvarint()
+			t.Spacing = d.uvarint()
+			t.Columns = d.uvarint()
+			t.TileCount = d.uvarint()
+			data.Tilesets = append(data.Tilesets, t)
+		}
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("error decoding world: %v", d.err)
+	}
+	return data, nil
+}
+
+type binaryEncoder struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryEncoder) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(e.scratch[:], v)
+	e.buf.Write(e.scratch[:2])
+}
+
+func (e *binaryEncoder) float32(v float32) {
+	binary.LittleEndian.PutUint32(e.scratch[:], math.Float32bits(v))
+	e.buf.Write(e.scratch[:4])
+}
+
+func (e *binaryEncoder) uvarint(v int) {
+	n := binary.PutUvarint(e.scratch[:], uint64(v))
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *binaryEncoder) varint(v int) {
+	n := binary.PutVarint(e.scratch[:], int64(v))
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uvarint(len(s))
+	e.buf.WriteString(s)
+}
+
+// binaryDecoder stops reading after first error,
+// all subsequent reads return zero values.
+type binaryDecoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *binaryDecoder) read(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		d.err = err
+	}
+	return b
+}
+
+func (d *binaryDecoder) uint16() uint16 {
+	return binary.LittleEndian.Uint16(d.read(2))
+}
+
+func (d *binaryDecoder) float32() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(d.read(4)))
+}
+
+func (d *binaryDecoder) uvarint() int {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = err
+		return 0
+	}
+	if v > math.MaxInt32 {
+		d.err = errors.New("value out of range")
+		return 0
+	}
+	return int(v)
+}
+
+func (d *binaryDecoder) varint() int {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	if err != nil {
+		d.err = err
+		return 0
+	}
+	return int(v)
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.uvarint()
+	if d.err == nil && n > d.r.Len() {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	return string(d.read(n))
+}
@@ -0,0 +1,178 @@
+package hashira
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newSaveTestWorld() *World {
+	w := New()
+	w.AddMap("island", 3, 2, 16, 8)
+	w.AddLayer("island", "grass", 0.5, "terrain")
+	w.AddLayerData("island", "grass", [][]int{
+		{0, 1, -1},
+		{300, 4, 5},
+	})
+	w.AddLayer("island", "buildings", -1.25, "")
+	w.AddMap("cave", 1, 1, 32, 32)
+	w.AddLayer("cave", "floor", 0, "dungeon")
+	w.AddLayerData("cave", "floor", [][]int{{7}})
+	w.DefineAnimation([]int{4, 5, 6}, 0.25)
+	w.DefineAnimation([]int{1, 9}, 1.5)
+	w.Resources.SetTilesetLayout("terrain", TilesetLayout{Margin: 1, Spacing: 2, Columns: 8, TileCount: 40})
+	return w
+}
+
+func TestWorldSaveLoadRoundTrip(t *testing.T) {
+	for _, format := range []SaveFormat{SaveFormatJSON, SaveFormatBinary} {
+		t.Run(string(format), func(t *testing.T) {
+			w := newSaveTestWorld()
+			b, err := w.Save(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := New()
+			if err := loaded.Load(b); err != nil {
+				t.Fatal(err)
+			}
+
+			want := &WorldData{
+				Version: SaveVersion,
+				Maps: []*MapData{
+					{
+						Name: "cave", Width: 1, Height: 1, TileWidth: 32, TileHeight: 32,
+						Layers: []*LayerData{
+							{Name: "floor", Z: 0, Tileset: "dungeon", Data: [][]int{{7}}},
+						},
+					},
+					{
+						Name: "island", Width: 3, Height: 2, TileWidth: 16, TileHeight: 8,
+						Layers: []*LayerData{
+							{Name: "grass", Z: 0.5, Tileset: "terrain", Data: [][]int{{0, 1, -1}, {300, 4, 5}}},
+							{Name: "buildings", Z: -1.25, Tileset: "", Data: [][]int{{0, 0, 0}, {0, 0, 0}}},
+						},
+					},
+				},
+				Animations: []*Animation{
+					{Frames: []int{1, 9}, Delay: 1.5},
+					{Frames: []int{4, 5, 6}, Delay: 0.25},
+				},
+				Tilesets: []*TilesetData{
+					{Name: "terrain", TilesetLayout: TilesetLayout{Margin: 1, Spacing: 2, Columns: 8, TileCount: 40}},
+				},
+			}
+			if got := loaded.Export(); !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip mismatch\ngot:  %+v\nwant: %+v", got, want)
+			}
+
+			layer := loaded.Maps.Get("island").Layers.Get("grass")
+			if got := layer.AnimatedTiles.Len(); got != 2 {
+				t.Errorf("got %d animated tiles after load, want 2", got)
+			}
+		})
+	}
+}
+
+func TestWorldLoadReplacesWorld(t *testing.T) {
+	b, err := newSaveTestWorld().Save(SaveFormatBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New()
+	w.AddMap("old", 1, 1, 16, 16)
+	w.DefineAnimation([]int{100, 101}, 1)
+
+	if err := w.Load(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Maps.Has("old") {
+		t.Error("old map was not removed")
+	}
+	if w.Animations.Has(100) {
+		t.Error("old animation was not removed")
+	}
+}
+
+func TestWorldLoadErrors(t *testing.T) {
+	binary, err := newSaveTestWorld().Save(SaveFormatBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"truncated binary":      binary[:len(binary)-3],
+		"future version":        []byte(`{"version": 99}`),
+		"invalid json":          []byte(`{"version":`),
+		"layer size mismatch":   []byte(`{"version":1,"maps":[{"name":"a","width":2,"height":1,"tile_width":16,"tile_height":16,"layers":[{"name":"l","data":[[1]]}]}]}`),
+		"zero tile size":        []byte(`{"version":1,"maps":[{"name":"a","width":1,"height":1,"tile_width":0,"tile_height":16,"layers":[]}]}`),
+		"negative width":        []byte(`{"version":1,"maps":[{"name":"a","width":-1,"height":1,"tile_width":16,"tile_height":16,"layers":[]}]}`),
+		"too many tiles":        []byte(`{"version":1,"maps":[{"name":"a","width":16384,"height":16384,"tile_width":16,"tile_height":16,"layers":[]}]}`),
+		"binary zero tile size": binaryMap(0, 16, 1, 1),
+		"binary huge map":       binaryMap(16, 16, 1<<20, 1<<20),
+		"zero animation delay":  []byte(`{"version":1,"animations":[{"frames":[1,2],"delay":0}]}`),
+		"negative margin":       []byte(`{"version":2,"tilesets":[{"name":"t","margin":-1}]}`),
+	}
+
+	for name, data := range tests {
+		w := New()
+		if err := w.Load(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// binaryMap crafts a binary world with a single map header and no layers.
+func binaryMap(tileWidth, tileHeight, width, height int) []byte {
+	e := &binaryEncoder{}
+	e.buf.Write(binaryMagic)
+	e.uint16(SaveVersion)
+	e.uvarint(1)
+	e.string("a")
+	e.uvarint(width)
+	e.uvarint(height)
+	e.uvarint(tileWidth)
+	e.uvarint(tileHeight)
+	e.uvarint(0)
+	e.uvarint(0)
+	e.uvarint(0)
+	return e.buf.Bytes()
+}
+
+func TestWorldLoadTilesetLayouts(t *testing.T) {
+	b, err := newSaveTestWorld().Save(SaveFormatBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New()
+	w.Resources.Tilesets.Set("terrain", &Tileset{Name: "terrain", Width: 64, Height: 64})
+
+	if err := w.Load(b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := TilesetLayout{Margin: 1, Spacing: 2, Columns: 8, TileCount: 40}
+	if got := w.Resources.GetTileset("terrain").TilesetLayout; got != want {
+		t.Errorf("got layout %+v of loaded tileset, want %+v", got, want)
+	}
+	if got := w.Resources.Layouts.Get("terrain"); got != want {
+		t.Errorf("got remembered layout %+v, want %+v", got, want)
+	}
+}
+
+func TestWorldLoadVersion1(t *testing.T) {
+	w := New()
+	if err := w.Load([]byte(`{"version":1,"maps":[{"name":"a","width":1,"height":1,"tile_width":16,"tile_height":16,"layers":[{"name":"l","data":[[1]]}]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Maps.Has("a") {
+		t.Error("map of version 1 world was not loaded")
+	}
+}
+
+func TestWorldSaveUnknownFormat(t *testing.T) {
+	if _, err := New().Save("xml"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,47 @@
+package hashira
+
+import "github.com/qbart/hashira/hgl"
+
+// EmptyTile marks cells without a tile, nothing is drawn there so lower layers stay visible.
+const EmptyTile = -1
+
+// Tile flags stored in high bits of tile ids in Layer.Data,
+// diagonal flip is applied first, then horizontal and vertical (same as in Tiled).
+const (
+	TileFlipHorizontal = 1 << 30
+	TileFlipVertical   = 1 << 29
+	TileFlipDiagonal   = 1 << 28
+
+	TileFlagsMask = TileFlipHorizontal | TileFlipVertical | TileFlipDiagonal
+)
+
+// TileID returns tile id without flip flags.
+func TileID(tile int) int {
+	if tile < 0 {
+		return tile
+	}
+	return tile &^ TileFlagsMask
+}
+
+// TileFlags returns flip flags of the tile.
+func TileFlags(tile int) int {
+	if tile < 0 {
+		return 0
+	}
+	return tile & TileFlagsMask
+}
+
+func tileQuadFlip(tile int) hgl.QuadFlip {
+	var flip hgl.QuadFlip
+	flags := TileFlags(tile)
+	if flags&TileFlipHorizontal != 0 {
+		flip |= hgl.FlipHorizontal
+	}
+	if flags&TileFlipVertical != 0 {
+		flip |= hgl.FlipVertical
+	}
+	if flags&TileFlipDiagonal != 0 {
+		flip |= hgl.FlipDiagonal
+	}
+	return flip
+}
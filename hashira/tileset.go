@@ -1,26 +1,114 @@
 package hashira
 
+import (
+	"fmt"
+
+	"github.com/qbart/hashira/hgl"
+)
+
+// TilesetLayout describes placement of tiles in the tileset image (same as in Tiled),
+// zero Columns and TileCount are computed from the image size.
+type TilesetLayout struct {
+	// pixels around all tiles
+	Margin int `json:"margin,omitempty"`
+	// pixels between neighbour tiles
+	Spacing   int `json:"spacing,omitempty"`
+	Columns   int `json:"columns,omitempty"`
+	TileCount int `json:"tile_count,omitempty"`
+}
+
+func (l TilesetLayout) Validate() error {
+	if l.Margin < 0 || l.Spacing < 0 || l.Columns < 0 || l.TileCount < 0 {
+		return fmt.Errorf("invalid tileset layout: %+v", l)
+	}
+	return nil
+}
+
 type Tileset struct {
-	Name   string
+	Name string
+	// size of the texture
 	Width  int
 	Height int
+	TilesetLayout
+
+	// Texture is the image tileset samples from (see Resources.PackAtlas), defaults to Name.
+	Texture string
+	// Regions remap tile ids to atlas rectangles, layout is not used when set.
+	Regions []hgl.AtlasRect
+}
+
+// TextureName returns name of the image to bind when drawing the tileset.
+func (t *Tileset) TextureName() string {
+	if t.Texture == "" {
+		return t.Name
+	}
+	return t.Texture
 }
 
-func (t *Tileset) TextureUV(tile int, tileWidth int, tileHeight int) (float32, float32, float32, float32) {
+// UVs are shrunk by a fraction of a texel so neighbour tiles do not bleed in.
+const uvTexelInset = 0.01
+
+// Grid returns number of columns and tiles for given tile size, Regions are not included.
+func (t *Tileset) Grid(tileWidth int, tileHeight int) (columns int, count int) {
+	if tileWidth+t.Spacing <= 0 || tileHeight+t.Spacing <= 0 {
+		return 0, 0
+	}
+	columns = t.Columns
+	if columns == 0 {
+		columns = (t.Width - 2*t.Margin + t.Spacing) / (tileWidth + t.Spacing)
+	}
+	count = t.TileCount
+	if count == 0 {
+		rows := (t.Height - 2*t.Margin + t.Spacing) / (tileHeight + t.Spacing)
+		count = columns * rows
+	}
+	if columns <= 0 || count < 0 {
+		return 0, 0
+	}
+	return columns, count
+}
+
+// TextureUV returns UVs of the tile (v0 is the top), tiles outside of the tileset are reported as errors.
+func (t *Tileset) TextureUV(tile int, tileWidth int, tileHeight int) (u0, v0, u1, v1 float32, err error) {
 	if t == nil {
-		return 0, 0, 1, 1
+		return 0, 0, 1, 1, nil
+	}
+	r, err := t.TileRect(tile, tileWidth, tileHeight)
+	if err != nil {
+		return 0, 0, 0, 0, err
 	}
-	tilesPerRow := t.Width / tileWidth
-	rowX := tile % tilesPerRow
-	rowY := tile / tilesPerRow
 	w := float32(t.Width)
 	h := float32(t.Height)
 
-	eps := float32(0.0001)
-	u0 := float32(rowX*tileWidth)/w + eps
-	v0 := float32(rowY*tileHeight)/h + eps
-	u1 := float32((rowX+1)*tileWidth)/w - eps
-	v1 := float32((rowY+1)*tileHeight)/h - eps
+	u0 = (float32(r.X) + uvTexelInset) / w
+	v0 = (float32(r.Y) + uvTexelInset) / h
+	u1 = (float32(r.X+r.Width) - uvTexelInset) / w
+	v1 = (float32(r.Y+r.Height) - uvTexelInset) / h
 
-	return u0, v0, u1, v1
+	return u0, v0, u1, v1, nil
+}
+
+// TileRect returns pixels of the tile in the texture.
+func (t *Tileset) TileRect(tile int, tileWidth int, tileHeight int) (hgl.AtlasRect, error) {
+	if t.Regions != nil {
+		if tile < 0 || tile >= len(t.Regions) {
+			return hgl.AtlasRect{}, fmt.Errorf("tile %d is out of range of tileset %q with %d tiles", tile, t.Name, len(t.Regions))
+		}
+		return t.Regions[tile], nil
+	}
+	columns, count := t.Grid(tileWidth, tileHeight)
+	if tile < 0 || tile >= count {
+		return hgl.AtlasRect{}, fmt.Errorf("tile %d is out of range of tileset %q with %d tiles", tile, t.Name, count)
+	}
+	r := hgl.AtlasRect{
+		X:      t.Margin + (tile%columns)*(tileWidth+t.Spacing),
+		Y:      t.Margin + (tile/columns)*(tileHeight+t.Spacing),
+		Width:  tileWidth,
+		Height: tileHeight,
+	}
+	// explicit columns or tile count can reach past the image
+	if r.X+r.Width > t.Width || r.Y+r.Height > t.Height {
+		return hgl.AtlasRect{}, fmt.Errorf("tile %d is out of range of tileset %q image %dx%d", tile, t.Name, t.Width, t.Height)
+	}
+	return r, nil
 }
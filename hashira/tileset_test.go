@@ -0,0 +1,94 @@
+package hashira
+
+import (
+	"testing"
+
+	"github.com/qbart/hashira/hmath"
+)
+
+func TestTilesetTextureUV(t *testing.T) {
+	tileset := &Tileset{Name: "test", Width: 64, Height: 32}
+
+	tests := []struct {
+		tile           int
+		u0, v0, u1, v1 float32
+	}{
+		{0, 0, 0, 0.25, 0.5},
+		{1, 0.25, 0, 0.5, 0.5},
+		{3, 0.75, 0, 1, 0.5},
+		{4, 0, 0.5, 0.25, 1},
+		{7, 0.75, 0.5, 1, 1},
+	}
+
+	for _, tt := range tests {
+		u0, v0, u1, v1, err := tileset.TextureUV(tt.tile, 16, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []float32{u0, v0, u1, v1}
+		want := []float32{tt.u0, tt.v0, tt.u1, tt.v1}
+		for i := range got {
+			if !hmath.CloseTo(got[i], want[i], 0.001) {
+				t.Errorf("tile %d: got uv %v, want %v", tt.tile, got, want)
+				break
+			}
+		}
+		if u0 <= tt.u0 || u1 >= tt.u1 || v0 <= tt.v0 || v1 >= tt.v1 {
+			t.Errorf("tile %d: uv %v is not shrunk to avoid bleeding", tt.tile, got)
+		}
+	}
+}
+
+func TestTilesetTextureUVWithoutTileset(t *testing.T) {
+	var tileset *Tileset
+
+	u0, v0, u1, v1, err := tileset.TextureUV(5, 16, 16)
+
+	if err != nil || u0 != 0 || v0 != 0 || u1 != 1 || v1 != 1 {
+		t.Errorf("got uv %v %v %v %v, want full texture", u0, v0, u1, v1)
+	}
+}
+
+func TestTilesetTextureUVMarginAndSpacing(t *testing.T) {
+	// 3 columns and 2 rows of 16x16 tiles with 1px margin and 2px spacing
+	tileset := &Tileset{Name: "test", Width: 54, Height: 36, TilesetLayout: TilesetLayout{Margin: 1, Spacing: 2}}
+
+	if columns, count := tileset.Grid(16, 16); columns != 3 || count != 6 {
+		t.Errorf("got grid %d columns %d tiles, want 3 columns 6 tiles", columns, count)
+	}
+
+	u0, v0, u1, v1, err := tileset.TextureUV(4, 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []float32{u0 * 54, v0 * 36, u1 * 54, v1 * 36}
+	want := []float32{19, 19, 35, 35}
+	for i := range got {
+		if !hmath.CloseTo(got[i], want[i], 0.02) {
+			t.Errorf("got pixels %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestTilesetTextureUVOutOfRange(t *testing.T) {
+	tests := []struct {
+		tileset *Tileset
+		tile    int
+	}{
+		{&Tileset{Name: "test", Width: 64, Height: 32}, 8},
+		{&Tileset{Name: "test", Width: 64, Height: 32}, -2},
+		// last row is not full
+		{&Tileset{Name: "test", Width: 64, Height: 32, TilesetLayout: TilesetLayout{TileCount: 6}}, 6},
+		{&Tileset{Name: "test", Width: 8, Height: 8}, 0},
+		// explicit layout past the image edge
+		{&Tileset{Name: "test", Width: 64, Height: 32, TilesetLayout: TilesetLayout{Columns: 8}}, 4},
+		{&Tileset{Name: "test", Width: 64, Height: 32, TilesetLayout: TilesetLayout{TileCount: 12}}, 8},
+	}
+
+	for _, tt := range tests {
+		if _, _, _, _, err := tt.tileset.TextureUV(tt.tile, 16, 16); err == nil {
+			t.Errorf("tile %d of %+v: expected error", tt.tile, tt.tileset)
+		}
+	}
+}
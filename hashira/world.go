@@ -1,78 +1,40 @@
 package hashira
 
 import (
+	"fmt"
+
 	"github.com/qbart/hashira/ds"
-	"github.com/qbart/hashira/hgl"
-	"github.com/qbart/hashira/hmath"
 )
 
 type World struct {
-	Resources *Resources
-	Maps      *ds.HashMap[string, *Map]
-	synced    bool
+	Resources  *Resources
+	Maps       *ds.HashMap[string, *Map]
+	Animations *ds.HashMap[int, *Animation]
+	// SkipUVs stops building chunk UVs for renderers reading tiles directly (ModeTileTexture),
+	// changed tiles are tracked anyway, see Chunk.DirtyTiles.
+	SkipUVs bool
+	synced  bool
 }
 
 func (w *World) AddMap(name string, width int, height int, tileWidth int, tileHeight int) *Map {
-	m := &Map{
-		Width:              width,
-		Height:             height,
-		TileWidth:          tileWidth,
-		TileHeight:         tileHeight,
-		Layers:             ds.NewHashMap[string, *Layer](),
-		SubMeshIndexByName: ds.NewHashMap[string, int](),
-	}
-	mesh := &hgl.Mesh{
-		Vertices:  hgl.NewVertexBuffer3f(m.VerticesNeeded()),
-		SubMeshes: make([]*hgl.SubMesh, 0),
-	}
-	m.Mesh = mesh
+	m := newMap(width, height, tileWidth, tileHeight)
 	w.Maps.Set(name, m)
-
-	tw := float32(tileWidth)
-	th := float32(tileHeight)
-
-	for my := 0; my < height; my++ {
-		for mx := 0; mx < width; mx++ {
-			z := float32(0)
-			i := (my*width + mx) * 6
-			x := float32(i / 6 % width)
-			y := float32(i / 6 / width)
-
-			// first triangle
-			//    2
-			//  / |
-			// 0--1
-			//
-
-			mesh.Vertices.Set(i+0, (x+0)*tw, (y+0)*th, z)
-			mesh.Vertices.Set(i+1, (x+1)*tw, (y+0)*th, z)
-			mesh.Vertices.Set(i+2, (x+1)*tw, (y+1)*th, z)
-
-			// second triangle
-			// 4--3
-			// | /
-			// 5
-			mesh.Vertices.Set(i+3, (x+1)*tw, (y+1)*th, z)
-			mesh.Vertices.Set(i+4, (x+0)*tw, (y+1)*th, z)
-			mesh.Vertices.Set(i+5, (x+0)*tw, (y+0)*th, z)
-		}
-	}
-
 	return m
 }
 
-func (w *World) AddLayer(mapName string, name string, z float32) *Layer {
+func (w *World) AddLayer(mapName string, name string, z float32, tileset string) *Layer {
 	m := w.Maps.Get(mapName)
-	subMesh := &hgl.SubMesh{
-		Model: hmath.TranslationMatrix(hmath.Vertex{0, 0, z}),
-		UVs:   hgl.NewVertexBuffer2f(m.VerticesNeeded()),
+	for _, c := range m.Chunks {
+		c.addSubMesh(z)
 	}
-	m.Mesh.SubMeshes = append(m.Mesh.SubMeshes, subMesh)
-	m.SubMeshIndexByName.Set(name, len(m.Mesh.SubMeshes)-1)
+	m.SubMeshIndexByName.Set(name, len(m.SubMeshLayerNames))
+	m.SubMeshLayerNames = append(m.SubMeshLayerNames, name)
 
 	layer := &Layer{
-		Z:    z,
-		Data: make([][]int, m.Height),
+		Z:             z,
+		Data:          make([][]int, m.Height),
+		Tileset:       tileset,
+		AnimatedTiles: ds.NewHashMap[int, *AnimatedTile](),
 	}
 	for i := range layer.Data {
 		layer.Data[i] = make([]int, m.Width)
@@ -82,36 +44,173 @@ func (w *World) AddLayer(mapName string, name string, z float32) *Layer {
 	return layer
 }
 
-func (w *World) AddLayerData(mapName string, name string, data [][]int) {
+// AddLayerData replaces all tiles of the layer,
+// first tile outside of the loaded tileset is reported (such tiles are not drawn).
+func (w *World) AddLayerData(mapName string, name string, data [][]int) error {
 	m := w.Maps.Get(mapName)
 	layer := m.Layers.Get(name)
-	submesh := m.Mesh.SubMeshes[m.SubMeshIndexByName.Get(name)]
+	index := m.SubMeshIndexByName.Get(name)
 
+	var err error
 	for my := 0; my < m.Height; my++ {
 		for mx := 0; mx < m.Width; mx++ {
 			layer.Data[my][mx] = data[my][mx]
+			w.trackAnimatedTile(layer, mx, my)
 			if w.synced {
-				w.buildTileUV(m, submesh, mx, my, layer.Data[my][mx])
+				if tileErr := w.updateTile(m, layer, index, mx, my, layer.VisibleTile(mx, my)); tileErr != nil && err == nil {
+					err = fmt.Errorf("layer %q tile (%d, %d): %v", name, mx, my, tileErr)
+				}
 			}
 		}
 	}
+	return err
 }
 
-func (w *World) SetTile(mapName string, layerName string, x, y int, tile int) {
+// SetTile assigns the tile, tile outside of the loaded tileset is reported and not drawn.
+func (w *World) SetTile(mapName string, layerName string, x, y int, tile int) error {
 	m := w.Maps.Get(mapName)
 	layer := m.Layers.Get(layerName)
 	layer.SetTile(x, y, tile)
+	w.trackAnimatedTile(layer, x, y)
 
-	layerMesh := m.Mesh.SubMeshes[m.SubMeshIndexByName.Get(layerName)]
-	w.buildTileUV(m, layerMesh, x, y, tile)
+	index := m.SubMeshIndexByName.Get(layerName)
+	return w.updateTile(m, layer, index, x, y, layer.VisibleTile(x, y))
 }
 
-func (w *World) buildTileUV(m *Map, s *hgl.SubMesh, x, y int, tile int) {
-	i := m.TileIndex(x, y)
+// DefineAnimation registers animation for its base tile (first frame)
+// and starts animating all tiles already placed with that id.
+func (w *World) DefineAnimation(frames []int, delay float32) error {
+	animation := &Animation{
+		Frames: frames,
+		Delay:  delay,
+	}
+	if err := animation.Validate(); err != nil {
+		return err
+	}
+	w.Animations.Set(animation.BaseTile(), animation)
+	w.retrackAnimatedTiles()
+	return nil
+}
 
-	u0, v0, u1, v1 := w.Resources.GetTileset().TextureUV(tile, m.TileWidth, m.TileHeight)
+// ClearAnimations removes all animations and restores base tiles.
+func (w *World) ClearAnimations() {
+	w.Animations.Clear()
+	w.retrackAnimatedTiles()
+}
 
-	s.UVs.SetQuad(i, u0, v0, u1, v1)
+// Update advances all animated tiles and updates UVs of tiles that changed frame.
+// First frame outside of its tileset is reported (such frames are not drawn).
+func (w *World) Update(dt float32) error {
+	var err error
+	w.Maps.ForEach(func(mapName string, m *Map) {
+		m.Layers.ForEach(func(layerName string, layer *Layer) {
+			if layer.AnimatedTiles.Len() == 0 {
+				return
+			}
+			index := m.SubMeshIndexByName.Get(layerName)
+			layer.AnimatedTiles.ForEach(func(_ int, a *AnimatedTile) {
+				if !a.Update(dt) || !w.synced {
+					return
+				}
+				if tileErr := w.updateTile(m, layer, index, a.X, a.Y, layer.VisibleTile(a.X, a.Y)); tileErr != nil && err == nil {
+					err = fmt.Errorf("map %q layer %q tile (%d, %d): %v", mapName, layerName, a.X, a.Y, tileErr)
+				}
+			})
+		})
+	})
+	return err
+}
+
+func (w *World) trackAnimatedTile(layer *Layer, x, y int) {
+	key := layer.cellIndex(x, y)
+	tile := TileID(layer.Tile(x, y))
+	if !w.Animations.Has(tile) {
+		layer.AnimatedTiles.Delete(key)
+		return
+	}
+	if a, ok := layer.animatedTile(x, y); ok && a.BaseTile() == tile {
+		return
+	}
+	layer.AnimatedTiles.Set(key, &AnimatedTile{
+		Animation: w.Animations.Get(tile),
+		X:         x,
+		Y:         y,
+	})
+}
+
+func (w *World) retrackAnimatedTiles() {
+	w.Maps.ForEach(func(_ string, m *Map) {
+		m.Layers.ForEach(func(_ string, layer *Layer) {
+			layer.AnimatedTiles.Clear()
+			for my := 0; my < m.Height; my++ {
+				for mx := 0; mx < m.Width; mx++ {
+					w.trackAnimatedTile(layer, mx, my)
+				}
+			}
+		})
+	})
+	w.Resync()
+}
+
+// updateTile marks the tile changed in its chunk and builds its UVs unless SkipUVs is set,
+// tiles outside of the tileset are reported in both cases.
+func (w *World) updateTile(m *Map, l *Layer, subMesh int, x, y int, tile int) error {
+	c, quad := m.ChunkTile(x, y)
+	c.markDirty(subMesh, quad)
+	if !w.SkipUVs {
+		return w.buildTileUV(m, l, subMesh, x, y, tile)
+	}
+
+	tileset := w.Resources.GetTileset(l.TilesetName())
+	if TileID(tile) == EmptyTile || tileset == nil {
+		return nil
+	}
+	_, err := tileset.TileRect(TileID(tile), m.TileWidth, m.TileHeight)
+	return err
+}
+
+// buildTileUV updates UVs of the tile in its chunk, UV buffer tracks changes for upload.
+// Quads of empty tiles and tiles outside of the tileset are hidden.
+func (w *World) buildTileUV(m *Map, l *Layer, subMesh int, x, y int, tile int) error {
+	c, quad := m.ChunkTile(x, y)
+	sm := c.Mesh.SubMeshes[subMesh]
+
+	if TileID(tile) == EmptyTile {
+		sm.UVs.SetQuad(quad, 0, 0, 0, 0)
+		sm.Indices.HideQuad(quad)
+		return nil
+	}
+
+	u0, v0, u1, v1, err := w.Resources.GetTileset(l.TilesetName()).TextureUV(TileID(tile), m.TileWidth, m.TileHeight)
+	if err != nil {
+		sm.UVs.SetQuad(quad, 0, 0, 0, 0)
+		sm.Indices.HideQuad(quad)
+		return err
+	}
+
+	sm.UVs.SetQuadFlipped(quad, u0, v0, u1, v1, tileQuadFlip(tile))
+	if sm.Indices.QuadHidden(quad) {
+		sm.Indices.ShowQuad(quad)
+	}
+	return nil
+}
+
+// BuildUVs builds UVs of all tiles of the map even when SkipUVs is set,
+// e.g. for SoftwareRenderer drawing a thumbnail. First tile outside of its tileset is reported.
+func (w *World) BuildUVs(mapName string) error {
+	m := w.Maps.Get(mapName)
+	var err error
+	m.Layers.ForEach(func(layerName string, layer *Layer) {
+		index := m.SubMeshIndexByName.Get(layerName)
+		for my := 0; my < m.Height; my++ {
+			for mx := 0; mx < m.Width; mx++ {
+				if tileErr := w.buildTileUV(m, layer, index, mx, my, layer.VisibleTile(mx, my)); tileErr != nil && err == nil {
+					err = fmt.Errorf("layer %q tile (%d, %d): %v", layerName, mx, my, tileErr)
+				}
+			}
+		}
+	})
+	return err
 }
 
 func (w *World) Resync() {
@@ -120,23 +219,27 @@ func (w *World) Resync() {
 
 // Sync rebuilds all meshes and UVs when needed
 // since maps and layers can be added before tileset is loaded.
-func (w *World) Sync() {
+// First tile outside of its tileset is reported, the rest of the world is synced anyway.
+func (w *World) Sync() error {
 	if w.synced {
-		return
+		return nil
 	}
-	w.Maps.ForEach(func(_ string, m *Map) {
+	var err error
+	w.Maps.ForEach(func(mapName string, m *Map) {
 		m.Layers.ForEach(func(layerName string, layer *Layer) {
 			index := m.SubMeshIndexByName.Get(layerName)
-			layerMesh := m.Mesh.SubMeshes[index]
 
 			for my := 0; my < m.Height; my++ {
 				for mx := 0; mx < m.Width; mx++ {
-					tile := layer.Tile(mx, my)
-					w.buildTileUV(m, layerMesh, mx, my, tile)
+					tile := layer.VisibleTile(mx, my)
+					if tileErr := w.updateTile(m, layer, index, mx, my, tile); tileErr != nil && err == nil {
+						err = fmt.Errorf("map %q layer %q tile (%d, %d): %v", mapName, layerName, mx, my, tileErr)
+					}
 				}
 			}
 		})
 	})
 
 	w.synced = true
+	return err
 }
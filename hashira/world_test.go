@@ -0,0 +1,428 @@
+package hashira
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+func newTestWorld() *World {
+	w := New()
+	w.Resources.Tilesets.Set(DefaultTileset, &Tileset{Name: DefaultTileset, Width: 64, Height: 64})
+	w.AddMap("main", 3, 2, 16, 16)
+	w.AddLayer("main", "ground", 0, "")
+	return w
+}
+
+// tileQuad returns quad index of the tile within its chunk.
+func tileQuad(m *Map, x, y int) int {
+	_, quad := m.ChunkTile(x, y)
+	return quad
+}
+
+func TestWorldAddMapMeshLayout(t *testing.T) {
+	w := newTestWorld()
+	m := w.Maps.Get("main")
+
+	if got, want := m.Chunks[0].Mesh.Vertices.Len(), 3*2*hgl.VerticesPerQuad; got != want {
+		t.Fatalf("got %d vertices, want %d", got, want)
+	}
+
+	// tile at mesh position (1, 1), second row from the bottom
+	i := (1*3 + 1) * hgl.VerticesPerQuad
+	want := [][3]float32{
+		{16, 16, 0}, {32, 16, 0}, {16, 32, 0}, {32, 32, 0},
+	}
+	for k, v := range want {
+		x, y, z := m.Chunks[0].Mesh.Vertices.At(i + k)
+		if x != v[0] || y != v[1] || z != v[2] {
+			t.Errorf("vertex %d: got (%v, %v, %v), want %v", k, x, y, z, v)
+		}
+	}
+}
+
+func TestWorldAddLayer(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayer("main", "top", 2, "buildings")
+	m := w.Maps.Get("main")
+
+	if got := len(m.Chunks[0].Mesh.SubMeshes); got != 2 {
+		t.Fatalf("got %d submeshes, want 2", got)
+	}
+	if got := m.SubMeshIndexByName.Get("top"); got != 1 {
+		t.Errorf("got submesh index %d, want 1", got)
+	}
+	if got := m.SubMeshLayer(1).TilesetName(); got != "buildings" {
+		t.Errorf("got tileset %q, want buildings", got)
+	}
+	if got := m.SubMeshLayer(0).TilesetName(); got != DefaultTileset {
+		t.Errorf("got tileset %q, want %q", got, DefaultTileset)
+	}
+	if got := m.Chunks[0].Mesh.SubMeshes[1].Model.Raw.Col(3)[2]; got != 2 {
+		t.Errorf("got model z %v, want 2", got)
+	}
+}
+
+func TestWorldAddLayerDataUVs(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayerData("main", "ground", [][]int{
+		{0, 1, 2},
+		{4, 5, 6},
+	})
+	m := w.Maps.Get("main")
+	uvs := m.Chunks[0].Mesh.SubMeshes[0].UVs
+
+	// top left tile of the data is the first tile of the top mesh row
+	assertQuadUV(t, uvs.At, tileQuad(m, 0, 0)*hgl.VerticesPerQuad, 0, 0, 0.25, 0.25)
+	assertQuadUV(t, uvs.At, tileQuad(m, 2, 1)*hgl.VerticesPerQuad, 0.5, 0.25, 0.75, 0.5)
+	if got := tileQuad(m, 0, 0); got != 3 {
+		t.Errorf("got quad %d, want 3", got)
+	}
+}
+
+func TestWorldSetTile(t *testing.T) {
+	w := newTestWorld()
+	w.SetTile("main", "ground", 1, 0, 5)
+	m := w.Maps.Get("main")
+
+	if got := m.Layers.Get("ground").Tile(1, 0); got != 5 {
+		t.Errorf("got tile %d, want 5", got)
+	}
+	assertQuadUV(t, m.Chunks[0].Mesh.SubMeshes[0].UVs.At, tileQuad(m, 1, 0)*hgl.VerticesPerQuad, 0.25, 0.25, 0.5, 0.5)
+}
+
+func TestWorldSyncAfterTilesetLoaded(t *testing.T) {
+	w := New()
+	w.AddMap("main", 1, 1, 16, 16)
+	w.AddLayer("main", "ground", 0, "")
+	w.AddLayerData("main", "ground", [][]int{{3}})
+
+	w.Resources.Tilesets.Set(DefaultTileset, &Tileset{Name: DefaultTileset, Width: 64, Height: 64})
+	w.Resync()
+	w.Sync()
+
+	assertQuadUV(t, w.Maps.Get("main").Chunks[0].Mesh.SubMeshes[0].UVs.At, 0, 0.75, 0, 1, 0.25)
+}
+
+func TestWorldFlippedTiles(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayerData("main", "ground", [][]int{
+		{1 | TileFlipHorizontal, 0, 0},
+		{0, 0, 0},
+	})
+	w.DefineAnimation([]int{0, 2}, 0.5)
+	w.SetTile("main", "ground", 1, 0, TileFlipVertical)
+	m := w.Maps.Get("main")
+	layer := m.Layers.Get("ground")
+	uvs := m.Chunks[0].Mesh.SubMeshes[0].UVs
+	w.Sync()
+
+	// horizontally flipped quad swaps left and right UVs
+	assertQuadUV(t, uvs.At, tileQuad(m, 0, 0)*hgl.VerticesPerQuad, 0.5, 0, 0.25, 0.25)
+
+	// animated tiles keep flags of the cell
+	if layer.AnimatedTiles.Len() != 5 {
+		t.Fatalf("got %d animated tiles, want 5", layer.AnimatedTiles.Len())
+	}
+	w.Update(0.5)
+	if got, want := layer.VisibleTile(1, 0), 2|TileFlipVertical; got != want {
+		t.Errorf("got visible tile %d, want %d", got, want)
+	}
+	assertQuadUV(t, uvs.At, tileQuad(m, 1, 0)*hgl.VerticesPerQuad, 0.5, 0.25, 0.75, 0)
+}
+
+func TestWorldEmptyTiles(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayerData("main", "ground", [][]int{
+		{EmptyTile, 1, 2},
+		{4, 5, 6},
+	})
+	m := w.Maps.Get("main")
+	c := m.Chunks[0]
+	indices := c.Mesh.SubMeshes[0].Indices
+	quad := tileQuad(m, 0, 0)
+
+	if !indices.QuadHidden(quad) || indices.QuadHidden(tileQuad(m, 1, 0)) {
+		t.Error("only quad of the empty tile should be hidden")
+	}
+
+	c.ClearDirty(0)
+	w.SetTile("main", "ground", 0, 0, 3)
+	if indices.QuadHidden(quad) {
+		t.Error("quad should be shown after tile is assigned")
+	}
+	assertQuadUV(t, c.Mesh.SubMeshes[0].UVs.At, quad*hgl.VerticesPerQuad, 0.75, 0, 1, 0.25)
+
+	c.ClearDirty(0)
+	w.SetTile("main", "ground", 0, 0, EmptyTile)
+	if !indices.QuadHidden(quad) {
+		t.Error("quad should be hidden again")
+	}
+	x0, y0, x1, y1, ok := c.DirtyTiles(0)
+	if got, want := [4]int{x0, y0, x1, y1}, [4]int{quad % c.Width, quad / c.Width, quad%c.Width + 1, quad/c.Width + 1}; !ok || got != want {
+		t.Errorf("got dirty tiles %v, want %v", got, want)
+	}
+	if got, want := indices.Data().Dirty(), (hgl.DirtyRange{From: quad * hgl.IndicesPerQuad, To: (quad + 1) * hgl.IndicesPerQuad}); got != want {
+		t.Errorf("got dirty indices %v, want %v", got, want)
+	}
+}
+
+func TestWorldTilesOutOfTileset(t *testing.T) {
+	w := newTestWorld()
+	m := w.Maps.Get("main")
+	indices := m.Chunks[0].Mesh.SubMeshes[0].Indices
+
+	// 64x64 tileset has 16 tiles
+	if err := w.SetTile("main", "ground", 1, 0, 16); err == nil {
+		t.Error("expected out of range error")
+	}
+	if !indices.QuadHidden(tileQuad(m, 1, 0)) {
+		t.Error("tile out of range should not be drawn")
+	}
+
+	err := w.AddLayerData("main", "ground", [][]int{
+		{0, 1, 2},
+		{3, 99, 5},
+	})
+	if err == nil || !strings.Contains(err.Error(), "tile (1, 1)") {
+		t.Errorf("got error %v, want error of tile (1, 1)", err)
+	}
+	if indices.QuadHidden(tileQuad(m, 1, 0)) {
+		t.Error("valid tile should be drawn again")
+	}
+
+	// tileset loaded later is checked on sync
+	w.Resources.Tilesets.Set(DefaultTileset, &Tileset{Name: DefaultTileset, Width: 64, Height: 64, TilesetLayout: TilesetLayout{TileCount: 4}})
+	w.Resync()
+	if err := w.Sync(); err == nil || !strings.Contains(err.Error(), "layer \"ground\"") {
+		t.Errorf("got error %v, want error of ground layer", err)
+	}
+}
+
+func TestWorldPickTileSkipsEmptyTiles(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayer("main", "top", 1, "")
+	w.AddLayerData("main", "top", [][]int{
+		{EmptyTile, 1, EmptyTile},
+		{EmptyTile, EmptyTile, EmptyTile},
+	})
+	w.AddLayerData("main", "ground", [][]int{
+		{EmptyTile, 4, 5},
+		{4, 5, 6},
+	})
+
+	// data row 0 is the top row of the map
+	tests := []struct {
+		x, y  int
+		layer string
+		tile  int
+	}{
+		{1, 0, "top", 1},
+		{2, 0, "ground", 5},
+		{0, 0, "top", EmptyTile},
+	}
+	for _, test := range tests {
+		pick, ok := w.PickTile(float32(test.x*16+8), float32((1-test.y)*16+8))
+		if !ok || pick.Layer != test.layer || pick.Tile != test.tile {
+			t.Errorf("tile (%d, %d): got %+v, want layer %s tile %d", test.x, test.y, pick, test.layer, test.tile)
+		}
+	}
+}
+
+func TestTileIDAndFlags(t *testing.T) {
+	tile := 7 | TileFlipHorizontal | TileFlipDiagonal
+
+	if got := TileID(tile); got != 7 {
+		t.Errorf("got id %d, want 7", got)
+	}
+	if got := TileFlags(tile); got != TileFlipHorizontal|TileFlipDiagonal {
+		t.Errorf("got flags %b", got)
+	}
+	if TileID(-1) != -1 || TileFlags(-1) != 0 {
+		t.Error("empty tile must not have flags")
+	}
+}
+
+func TestWorldAnimations(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayerData("main", "ground", [][]int{
+		{1, 0, 0},
+		{0, 0, 1},
+	})
+	w.DefineAnimation([]int{1, 2}, 0.5)
+	m := w.Maps.Get("main")
+	layer := m.Layers.Get("ground")
+	w.Sync()
+
+	if got := layer.AnimatedTiles.Len(); got != 2 {
+		t.Fatalf("got %d animated tiles, want 2", got)
+	}
+
+	w.Update(0.25)
+	if got := layer.VisibleTile(0, 0); got != 1 {
+		t.Errorf("got visible tile %d before delay, want 1", got)
+	}
+
+	w.Update(0.25)
+	if got := layer.VisibleTile(0, 0); got != 2 {
+		t.Errorf("got visible tile %d after delay, want 2", got)
+	}
+	if got := layer.Tile(0, 0); got != 1 {
+		t.Errorf("got tile %d, layer data must keep base tile", got)
+	}
+	assertQuadUV(t, m.Chunks[0].Mesh.SubMeshes[0].UVs.At, tileQuad(m, 0, 0)*hgl.VerticesPerQuad, 0.5, 0, 0.75, 0.25)
+
+	w.SetTile("main", "ground", 0, 0, 3)
+	if got := layer.AnimatedTiles.Len(); got != 1 {
+		t.Errorf("got %d animated tiles after replacing tile, want 1", got)
+	}
+
+	w.ClearAnimations()
+	w.Sync()
+	if got := layer.AnimatedTiles.Len(); got != 0 {
+		t.Errorf("got %d animated tiles after clear, want 0", got)
+	}
+	if got := layer.VisibleTile(2, 1); got != 1 {
+		t.Errorf("got visible tile %d after clear, want base tile 1", got)
+	}
+}
+
+func TestWorldAnimationFrameOutOfRange(t *testing.T) {
+	w := newTestWorld()
+	w.AddLayerData("main", "ground", [][]int{
+		{1, 0, 0},
+		{0, 0, 0},
+	})
+	w.DefineAnimation([]int{1, 100}, 0.5)
+	w.Sync()
+
+	if err := w.Update(0.25); err != nil {
+		t.Fatalf("unexpected error before frame change: %v", err)
+	}
+	if err := w.Update(0.25); err == nil {
+		t.Error("expected error for frame outside of the tileset")
+	}
+}
+
+func TestWorldDefineAnimationErrors(t *testing.T) {
+	w := newTestWorld()
+	tests := map[string]struct {
+		frames []int
+		delay  float32
+	}{
+		"no frames":      {nil, 0.5},
+		"single frame":   {[]int{1}, 0.5},
+		"zero delay":     {[]int{1, 2}, 0},
+		"negative delay": {[]int{1, 2}, -1},
+	}
+	for name, tt := range tests {
+		if err := w.DefineAnimation(tt.frames, tt.delay); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if got := w.Animations.Len(); got != 0 {
+		t.Errorf("got %d animations, want 0", got)
+	}
+}
+
+func assertQuadUV(t *testing.T, at func(int) (float32, float32), i int, u0, v0, u1, v1 float32) {
+	t.Helper()
+
+	// quad order matches vertices: bottom left, bottom right, top left, top right
+	want := [][2]float32{{u0, v1}, {u1, v1}, {u0, v0}, {u1, v0}}
+	for k, uv := range want {
+		u, v := at(i + k)
+		if !hmath.CloseTo(u, uv[0], 0.001) || !hmath.CloseTo(v, uv[1], 0.001) {
+			t.Errorf("uv %d of quad at %d: got (%v, %v), want %v", k, i, u, v, uv)
+		}
+	}
+}
+
+func TestWorldDirtyChunks(t *testing.T) {
+	w := New()
+	w.Resources.Tilesets.Set(DefaultTileset, &Tileset{Name: DefaultTileset, Width: 64, Height: 64})
+	w.AddMap("main", ChunkSize*2, ChunkSize, 16, 16)
+	w.AddLayer("main", "ground", 0, "")
+	m := w.Maps.Get("main")
+
+	// new layer has to be uploaded whole
+	for i, c := range m.Chunks {
+		x0, y0, x1, y1, ok := c.DirtyTiles(0)
+		if got, want := [4]int{x0, y0, x1, y1}, [4]int{0, 0, c.Width, c.Height}; !ok || got != want {
+			t.Errorf("chunk %d: got dirty tiles %v, want %v", i, got, want)
+		}
+		c.ClearDirty(0)
+	}
+
+	w.SetTile("main", "ground", ChunkSize+2, ChunkSize-1, 1)
+	w.SetTile("main", "ground", ChunkSize+5, ChunkSize-1, 1)
+
+	if _, _, _, _, ok := m.Chunks[0].DirtyTiles(0); ok {
+		t.Error("want untouched chunk to stay clean")
+	}
+	x0, y0, x1, y1, ok := m.Chunks[1].DirtyTiles(0)
+	if got, want := [4]int{x0, y0, x1, y1}, [4]int{2, 0, 6, 1}; !ok || got != want {
+		t.Errorf("got dirty tiles %v, want %v", got, want)
+	}
+}
+
+func TestMapDirtyRect(t *testing.T) {
+	w := newTestWorld()
+	m := w.Maps.Get("main")
+	c := m.Chunks[0]
+	c.ClearDirty(0)
+
+	if _, _, _, _, ok := m.DirtyRect(c, 0); ok {
+		t.Error("expected clean chunk")
+	}
+
+	w.SetTile("main", "ground", 1, 0, 2)
+	x0, y0, x1, y1, ok := m.DirtyRect(c, 0)
+	if got, want := [4]int{x0, y0, x1, y1}, [4]int{1, 0, 2, 1}; !ok || got != want {
+		t.Errorf("got %v, want single tile %v", got, want)
+	}
+
+	w.SetTile("main", "ground", 2, 1, 2)
+	x0, y0, x1, y1, ok = m.DirtyRect(c, 0)
+	if got, want := [4]int{x0, y0, x1, y1}, [4]int{1, 0, 3, 2}; !ok || got != want {
+		t.Errorf("got %v, want bounding rectangle %v", got, want)
+	}
+}
+
+func TestWorldSkipUVs(t *testing.T) {
+	w := newTestWorld()
+	w.SkipUVs = true
+	m := w.Maps.Get("main")
+	c := m.Chunks[0]
+	uvs := c.Mesh.SubMeshes[0].UVs
+	c.ClearDirty(0)
+	quad := tileQuad(m, 1, 0)
+	u, v := uvs.At(quad * hgl.VerticesPerQuad)
+
+	if err := w.SetTile("main", "ground", 1, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if gu, gv := uvs.At(quad * hgl.VerticesPerQuad); gu != u || gv != v {
+		t.Error("UVs should not be built")
+	}
+	if !uvs.Data().Dirty().Empty() {
+		t.Error("UV buffer should stay clean")
+	}
+	x0, y0, x1, y1, ok := m.DirtyRect(c, 0)
+	if got, want := [4]int{x0, y0, x1, y1}, [4]int{1, 0, 2, 1}; !ok || got != want {
+		t.Errorf("got dirty rect %v, want %v", got, want)
+	}
+
+	if err := w.SetTile("main", "ground", 1, 0, 100); err == nil {
+		t.Error("expected error for tile outside of the tileset")
+	}
+
+	if err := w.BuildUVs("main"); err == nil {
+		t.Error("expected error for tile outside of the tileset")
+	}
+	w.SetTile("main", "ground", 1, 0, 3)
+	w.BuildUVs("main")
+	assertQuadUV(t, uvs.At, quad*hgl.VerticesPerQuad, 0.75, 0, 1, 0.25)
+}
@@ -0,0 +1,159 @@
+package hgl
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/qbart/hashira/ds"
+)
+
+// MaxAtlasSize is the largest atlas width and height, WebGL2 guarantees at least 4096.
+const MaxAtlasSize = 4096
+
+// AtlasRect is a rectangle in pixels, y grows down.
+type AtlasRect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// AtlasSource is an image with tiles to be packed, tiles keep their order in the atlas table.
+type AtlasSource struct {
+	Name  string
+	Image *Image
+	Tiles []AtlasRect
+}
+
+// Atlas is a single texture with tiles of all sources.
+type Atlas struct {
+	Image   *Image
+	Extrude int
+	// tile rectangles in the atlas (without extruded border) by source name
+	Tiles *ds.HashMap[string, []AtlasRect]
+}
+
+// BuildAtlas packs tiles of all sources into a power of two image,
+// border pixels of every tile are repeated extrude times so filtering never reaches neighbour tiles.
+func BuildAtlas(sources []AtlasSource, extrude int) (*Atlas, error) {
+	if extrude < 0 {
+		return nil, fmt.Errorf("invalid extrude: %v", extrude)
+	}
+
+	cells := make([]AtlasRect, 0)
+	area := 0
+	names := make(map[string]bool)
+	for _, s := range sources {
+		if names[s.Name] {
+			return nil, fmt.Errorf("duplicated source: %v", s.Name)
+		}
+		names[s.Name] = true
+		if s.Image == nil {
+			return nil, fmt.Errorf("source %q has no image", s.Name)
+		}
+		for i, t := range s.Tiles {
+			if t.Width <= 0 || t.Height <= 0 || t.X < 0 || t.Y < 0 || t.X+t.Width > s.Image.Width || t.Y+t.Height > s.Image.Height {
+				return nil, fmt.Errorf("source %q tile %d %v is out of image bounds %dx%d", s.Name, i, t, s.Image.Width, s.Image.Height)
+			}
+			cell := AtlasRect{Width: t.Width + 2*extrude, Height: t.Height + 2*extrude}
+			cells = append(cells, cell)
+			area += cell.Width * cell.Height
+		}
+	}
+
+	width, height, err := packAtlas(cells, area)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	atlas := &Atlas{
+		Image:   &Image{Image: dst, Width: width, Height: height},
+		Extrude: extrude,
+		Tiles:   ds.NewHashMap[string, []AtlasRect](),
+	}
+	i := 0
+	for _, s := range sources {
+		tiles := make([]AtlasRect, len(s.Tiles))
+		for k, t := range s.Tiles {
+			cell := cells[i]
+			i++
+			tiles[k] = AtlasRect{X: cell.X + extrude, Y: cell.Y + extrude, Width: t.Width, Height: t.Height}
+			copyExtruded(dst, tiles[k], s.Image.Image, t, extrude)
+		}
+		atlas.Tiles.Set(s.Name, tiles)
+	}
+
+	return atlas, nil
+}
+
+// packAtlas places cells into shelves (rows), size starts as the smallest square
+// that could fit all cells and grows until they fit.
+func packAtlas(cells []AtlasRect, area int) (int, int, error) {
+	size := nextPowerOfTwo(int(math.Ceil(math.Sqrt(float64(area)))))
+	width, height := size, size
+	for width <= MaxAtlasSize && height <= MaxAtlasSize {
+		if packShelves(cells, width) <= height {
+			return width, height, nil
+		}
+		if width == height {
+			width *= 2
+		} else {
+			height *= 2
+		}
+	}
+	return 0, 0, fmt.Errorf("tiles do not fit into %dx%d atlas", MaxAtlasSize, MaxAtlasSize)
+}
+
+// packShelves sets positions of cells and returns used height, cells wider than width never fit.
+func packShelves(cells []AtlasRect, width int) int {
+	x, y, rowHeight := 0, 0, 0
+	for i := range cells {
+		c := &cells[i]
+		if c.Width > width {
+			return math.MaxInt32
+		}
+		if x+c.Width > width {
+			x = 0
+			y += rowHeight
+			rowHeight = 0
+		}
+		c.X, c.Y = x, y
+		x += c.Width
+		if c.Height > rowHeight {
+			rowHeight = c.Height
+		}
+	}
+	return y + rowHeight
+}
+
+// copyExtruded copies tile src to dst, pixels around dst are filled with the nearest border pixel.
+func copyExtruded(dst *image.RGBA, to AtlasRect, img image.Image, src AtlasRect, extrude int) {
+	bounds := img.Bounds()
+	for y := -extrude; y < src.Height+extrude; y++ {
+		sy := clampAtlas(y, 0, src.Height-1)
+		for x := -extrude; x < src.Width+extrude; x++ {
+			sx := clampAtlas(x, 0, src.Width-1)
+			dst.Set(to.X+x, to.Y+y, img.At(bounds.Min.X+src.X+sx, bounds.Min.Y+src.Y+sy))
+		}
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+func clampAtlas(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package hgl
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// newTestAtlasImage returns image of tiles filled with colors by tile index (tile i has red = i + 1),
+// top left texel of every tile is white.
+func newTestAtlasImage(columns, rows, tileSize int) *Image {
+	img := image.NewRGBA(image.Rect(0, 0, columns*tileSize, rows*tileSize))
+	for y := 0; y < rows*tileSize; y++ {
+		for x := 0; x < columns*tileSize; x++ {
+			tile := (y/tileSize)*columns + x/tileSize
+			c := color.RGBA{uint8(tile + 1), 0, 0, 255}
+			if x%tileSize == 0 && y%tileSize == 0 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return &Image{Image: img, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+}
+
+func gridTiles(columns, rows, tileSize int) []AtlasRect {
+	tiles := make([]AtlasRect, 0)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			tiles = append(tiles, AtlasRect{X: x * tileSize, Y: y * tileSize, Width: tileSize, Height: tileSize})
+		}
+	}
+	return tiles
+}
+
+func TestBuildAtlas(t *testing.T) {
+	sources := []AtlasSource{
+		{Name: "a", Image: newTestAtlasImage(3, 2, 4), Tiles: gridTiles(3, 2, 4)},
+		{Name: "b", Image: newTestAtlasImage(2, 1, 8), Tiles: gridTiles(2, 1, 8)},
+	}
+
+	atlas, err := BuildAtlas(sources, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w, h := atlas.Image.Width, atlas.Image.Height; w&(w-1) != 0 || h&(h-1) != 0 {
+		t.Errorf("got size %dx%d, want power of two", w, h)
+	}
+	if got := len(atlas.Tiles.Get("a")); got != 6 {
+		t.Fatalf("got %d tiles of a, want 6", got)
+	}
+	if got := len(atlas.Tiles.Get("b")); got != 2 {
+		t.Fatalf("got %d tiles of b, want 2", got)
+	}
+
+	img := atlas.Image.Image.(*image.RGBA)
+	all := append(append([]AtlasRect(nil), atlas.Tiles.Get("a")...), atlas.Tiles.Get("b")...)
+	for i, r := range all {
+		grown := image.Rect(r.X-2, r.Y-2, r.X+r.Width+2, r.Y+r.Height+2)
+		if !grown.In(img.Bounds()) {
+			t.Errorf("tile %d %v with border is outside of the atlas", i, r)
+		}
+		for k, other := range all {
+			o := image.Rect(other.X-2, other.Y-2, other.X+other.Width+2, other.Y+other.Height+2)
+			if k != i && grown.Overlaps(o) {
+				t.Errorf("tile %d %v overlaps tile %d %v", i, r, k, other)
+			}
+		}
+	}
+
+	// tile 4 of a keeps its pixels, extruded border repeats edge pixels
+	r := atlas.Tiles.Get("a")[4]
+	if got, want := img.RGBAAt(r.X, r.Y), (color.RGBA{255, 255, 255, 255}); got != want {
+		t.Errorf("got top left %v, want %v", got, want)
+	}
+	if got, want := img.RGBAAt(r.X+1, r.Y+1), (color.RGBA{5, 0, 0, 255}); got != want {
+		t.Errorf("got inside %v, want %v", got, want)
+	}
+	if got, want := img.RGBAAt(r.X-2, r.Y-2), (color.RGBA{255, 255, 255, 255}); got != want {
+		t.Errorf("got extruded corner %v, want %v", got, want)
+	}
+	if got, want := img.RGBAAt(r.X+r.Width+1, r.Y+1), (color.RGBA{5, 0, 0, 255}); got != want {
+		t.Errorf("got extruded right edge %v, want %v", got, want)
+	}
+}
+
+func TestBuildAtlasErrors(t *testing.T) {
+	img := newTestAtlasImage(2, 2, 4)
+	tests := []struct {
+		name    string
+		sources []AtlasSource
+		extrude int
+		err     string
+	}{
+		{"out of bounds", []AtlasSource{{Name: "a", Image: img, Tiles: []AtlasRect{{X: 6, Y: 0, Width: 4, Height: 4}}}}, 0, "out of image bounds"},
+		{"duplicated", []AtlasSource{{Name: "a", Image: img}, {Name: "a", Image: img}}, 0, "duplicated source"},
+		{"too large", []AtlasSource{{Name: "a", Image: img, Tiles: []AtlasRect{{Width: 4, Height: 4}}}}, MaxAtlasSize, "do not fit"},
+		{"negative extrude", []AtlasSource{{Name: "a", Image: img}}, -1, "invalid extrude"},
+	}
+
+	for _, tt := range tests {
+		_, err := BuildAtlas(tt.sources, tt.extrude)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
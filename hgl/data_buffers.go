@@ -1,9 +1,8 @@
 package hgl
 
 import (
-	"bytes"
 	"encoding/binary"
-	"fmt"
+	"math"
 )
 
 type BufferData interface {
@@ -11,18 +10,51 @@ type BufferData interface {
 	Len() int
 }
 
+// DirtyRange is a range of elements [From, To) modified since last upload.
+type DirtyRange struct {
+	From int
+	To   int
+}
+
+func (r DirtyRange) Empty() bool {
+	return r.From >= r.To
+}
+
+func (r *DirtyRange) Add(i int) {
+	if r.Empty() {
+		r.From, r.To = i, i+1
+		return
+	}
+	if i < r.From {
+		r.From = i
+	}
+	if i+1 > r.To {
+		r.To = i + 1
+	}
+}
+
+// NewFloat32ArrayBuffer copies data, the whole buffer is dirty until first upload.
 func NewFloat32ArrayBuffer(data []float32) *Float32ArrayBuffer {
-	return &Float32ArrayBuffer{
-		Data:  data,
-		Cache: new(bytes.Buffer),
+	f := &Float32ArrayBuffer{
+		data:  make([]float32, len(data)),
+		bytes: make([]byte, len(data)*4),
 	}
+	for i, x := range data {
+		f.Set(i, x)
+	}
+	return f
 }
 
+// NewUInt32ArrayBuffer copies data, the whole buffer is dirty until first upload.
 func NewUInt32ArrayBuffer(data []uint32) *UInt32ArrayBuffer {
-	return &UInt32ArrayBuffer{
-		Data:  data,
-		Cache: new(bytes.Buffer),
+	u := &UInt32ArrayBuffer{
+		data:  make([]uint32, len(data)),
+		bytes: make([]byte, len(data)*4),
+	}
+	for i, x := range data {
+		u.Set(i, x)
 	}
+	return u
 }
 
 func NewByteArrayBuffer(data []byte) *ByteArrayBuffer {
@@ -30,48 +62,85 @@ func NewByteArrayBuffer(data []byte) *ByteArrayBuffer {
 	return &buf
 }
 
+// Float32ArrayBuffer keeps values together with their little endian encoding
+// (updated in place by Set) so uploads don't have to convert anything.
 type Float32ArrayBuffer struct {
-	Data  []float32
-	Cache *bytes.Buffer
+	data  []float32
+	bytes []byte
+	dirty DirtyRange
 }
 
+// UInt32ArrayBuffer keeps values together with their little endian encoding, see Float32ArrayBuffer.
 type UInt32ArrayBuffer struct {
-	Data  []uint32
-	Cache *bytes.Buffer
+	data  []uint32
+	bytes []byte
+	dirty DirtyRange
 }
 
 type ByteArrayBuffer []byte
 
+func (f *Float32ArrayBuffer) At(i int) float32 {
+	return f.data[i]
+}
+
+func (f *Float32ArrayBuffer) Set(i int, x float32) {
+	f.data[i] = x
+	binary.LittleEndian.PutUint32(f.bytes[i*4:], math.Float32bits(x))
+	f.dirty.Add(i)
+}
+
 func (f *Float32ArrayBuffer) Bytes() []byte {
-	f.Cache.Reset() // reset does not shrink the buffer so we can reuse it
-	for _, x := range f.Data {
-		err := binary.Write(f.Cache, binary.LittleEndian, x)
-		if err != nil {
-			fmt.Println("Float32ArrayBuffer.Bytes error:", err)
-			return nil
-		}
-	}
-	return f.Cache.Bytes()
+	return f.bytes
+}
+
+// BytesRange returns encoded elements [from, to), used for partial uploads.
+func (f *Float32ArrayBuffer) BytesRange(from, to int) []byte {
+	return f.bytes[from*4 : to*4]
 }
 
 func (f *Float32ArrayBuffer) Len() int {
-	return len(f.Data)
+	return len(f.data)
 }
 
-func (u UInt32ArrayBuffer) Bytes() []byte {
-	u.Cache.Reset() // reset does not shrink the buffer so we can reuse it
-	for _, x := range u.Data {
-		err := binary.Write(u.Cache, binary.LittleEndian, x)
-		if err != nil {
-			fmt.Println("UInt32ArrayBuffer.Bytes error:", err)
-			return nil
-		}
-	}
-	return u.Cache.Bytes()
+// Dirty returns elements changed since last ClearDirty.
+func (f *Float32ArrayBuffer) Dirty() DirtyRange {
+	return f.dirty
+}
+
+// ClearDirty should be called once the buffer is uploaded.
+func (f *Float32ArrayBuffer) ClearDirty() {
+	f.dirty = DirtyRange{}
+}
+
+func (u *UInt32ArrayBuffer) At(i int) uint32 {
+	return u.data[i]
+}
+
+func (u *UInt32ArrayBuffer) Set(i int, x uint32) {
+	u.data[i] = x
+	binary.LittleEndian.PutUint32(u.bytes[i*4:], x)
+	u.dirty.Add(i)
+}
+
+func (u *UInt32ArrayBuffer) Bytes() []byte {
+	return u.bytes
+}
+
+// BytesRange returns encoded elements [from, to), used for partial uploads.
+func (u *UInt32ArrayBuffer) BytesRange(from, to int) []byte {
+	return u.bytes[from*4 : to*4]
+}
+
+func (u *UInt32ArrayBuffer) Len() int {
+	return len(u.data)
+}
+
+func (u *UInt32ArrayBuffer) Dirty() DirtyRange {
+	return u.dirty
 }
 
-func (u UInt32ArrayBuffer) Len() int {
-	return len(u.Data)
+func (u *UInt32ArrayBuffer) ClearDirty() {
+	u.dirty = DirtyRange{}
 }
 
 func (b ByteArrayBuffer) Bytes() []byte {
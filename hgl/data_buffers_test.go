@@ -0,0 +1,90 @@
+package hgl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// binaryWriteBytes is the previous encoding, kept as a reference for tests and benchmarks.
+func binaryWriteBytes(data []float32) []byte {
+	var buf bytes.Buffer
+	for _, x := range data {
+		binary.Write(&buf, binary.LittleEndian, x)
+	}
+	return buf.Bytes()
+}
+
+func testFloats(n int) []float32 {
+	data := make([]float32, n)
+	for i := range data {
+		data[i] = float32(i)*0.37 - 100
+	}
+	return data
+}
+
+func TestFloat32ArrayBufferSet(t *testing.T) {
+	data := testFloats(16)
+	buf := NewFloat32ArrayBuffer(data)
+	if got, want := buf.Dirty(), (DirtyRange{From: 0, To: 16}); got != want {
+		t.Errorf("got dirty %v, want new buffer to be dirty %v", got, want)
+	}
+	buf.ClearDirty()
+
+	buf.Set(9, 1.5)
+	buf.Set(4, -3)
+	data[9] = 1.5
+	data[4] = -3
+
+	if got, want := buf.Bytes(), binaryWriteBytes(data); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if got, want := buf.Dirty(), (DirtyRange{From: 4, To: 10}); got != want {
+		t.Errorf("got dirty %v, want %v", got, want)
+	}
+	if got, want := buf.BytesRange(4, 5), binaryWriteBytes([]float32{-3}); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if got := buf.At(9); got != 1.5 {
+		t.Errorf("got %v, want 1.5", got)
+	}
+}
+
+func TestUInt32ArrayBufferBytes(t *testing.T) {
+	buf := NewUInt32ArrayBuffer([]uint32{1, 0x01020304})
+	buf.Set(0, 0xffffffff)
+
+	want := []byte{0xff, 0xff, 0xff, 0xff, 0x04, 0x03, 0x02, 0x01}
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+// chunk of 32x32 tiles, 4 UVs per tile
+const benchmarkFloats = 32 * 32 * VerticesPerQuad * 2
+
+func BenchmarkFloat32ArrayBufferBinaryWrite(b *testing.B) {
+	data := testFloats(benchmarkFloats)
+	for i := 0; i < b.N; i++ {
+		data[i%benchmarkFloats] = float32(i)
+		binaryWriteBytes(data)
+	}
+}
+
+func BenchmarkFloat32ArrayBufferBytes(b *testing.B) {
+	buf := NewFloat32ArrayBuffer(testFloats(benchmarkFloats))
+	for i := 0; i < b.N; i++ {
+		buf.Set(i%benchmarkFloats, float32(i))
+		buf.Bytes()
+	}
+}
+
+func BenchmarkFloat32ArrayBufferDirtyQuad(b *testing.B) {
+	uvs := NewVertexBuffer2f(benchmarkFloats / 2)
+	for i := 0; i < b.N; i++ {
+		uvs.SetQuad(i%(benchmarkFloats/8), 0.25, 0.25, 0.5, 0.5)
+		dirty := uvs.Data().Dirty()
+		uvs.Data().BytesRange(dirty.From, dirty.To)
+		uvs.Data().ClearDirty()
+	}
+}
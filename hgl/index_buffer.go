@@ -1,54 +1,77 @@
 package hgl
 
 type IndexBuffer struct {
-	data []uint32
+	data *UInt32ArrayBuffer
 }
 
 func NewIndexBuffer(n int) *IndexBuffer {
 	return &IndexBuffer{
-		data: make([]uint32, n),
+		data: NewUInt32ArrayBuffer(make([]uint32, n)),
 	}
 }
 
+// NewQuadIndexBuffer returns indices of n quads,
+// each quad has 4 vertices (bottom left, bottom right, top left, top right).
+func NewQuadIndexBuffer(n int) *IndexBuffer {
+	ib := NewIndexBuffer(n * IndicesPerQuad)
+	for i := 0; i < n; i++ {
+		ib.ShowQuad(i)
+	}
+	return ib
+}
+
 func (ib *IndexBuffer) Len() int {
-	return len(ib.data)
+	return ib.data.Len()
 }
 
 func (ib *IndexBuffer) At(i int) uint32 {
-	return ib.data[i]
+	return ib.data.At(i)
 }
 
 func (ib *IndexBuffer) Set(i int, v uint32) {
-	ib.data[i] = v
+	ib.data.Set(i, v)
 }
 
 func (ib *IndexBuffer) SetTriangle(i int, a, b, c uint32) {
 	i *= 3
-	ib.data[i+0] = a
-	ib.data[i+1] = b
-	ib.data[i+2] = c
+	ib.data.Set(i+0, a)
+	ib.data.Set(i+1, b)
+	ib.data.Set(i+2, c)
 }
 
 func (ib *IndexBuffer) SetQuad(i int, a, b, c, d uint32) {
-	i *= 6
-
 	// first triangle
 	// c
 	// | \
 	// a--b
-	ib.data[i+0] = a
-	ib.data[i+1] = b
-	ib.data[i+2] = c
+	ib.SetTriangle(i*2, a, b, c)
 
 	// second triangle
 	// c--d
 	//  \ |
 	//    b
-	ib.data[i+3] = c
-	ib.data[i+4] = b
-	ib.data[i+5] = d
+	ib.SetTriangle(i*2+1, c, b, d)
+}
+
+// ShowQuad sets indices of quad i to its own vertices (see NewQuadIndexBuffer).
+func (ib *IndexBuffer) ShowQuad(i int) {
+	v := uint32(i * VerticesPerQuad)
+	ib.SetQuad(i, v+0, v+1, v+2, v+3)
+}
+
+// HideQuad collapses both triangles of quad i into a single vertex,
+// degenerate triangles produce no fragments so nothing is drawn.
+func (ib *IndexBuffer) HideQuad(i int) {
+	v := uint32(i * VerticesPerQuad)
+	ib.SetQuad(i, v, v, v, v)
+}
+
+// QuadHidden reports whether quad i was hidden with HideQuad.
+func (ib *IndexBuffer) QuadHidden(i int) bool {
+	i *= IndicesPerQuad
+	return ib.data.At(i) == ib.data.At(i+1)
 }
 
-func (ib *IndexBuffer) Data() []uint32 {
+func (ib *IndexBuffer) Data() *UInt32ArrayBuffer {
 	return ib.data
 }
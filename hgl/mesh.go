@@ -2,12 +2,21 @@ package hgl
 
 import "github.com/qbart/hashira/hmath"
 
+const (
+	// every quad has its own vertices so UVs can differ between neighbours
+	VerticesPerQuad = 4
+	// 2 triangles, see IndexBuffer.SetQuad
+	IndicesPerQuad = 6
+)
+
 type Mesh struct {
 	Vertices  *VertexBuffer3f
 	SubMeshes []*SubMesh
 }
 
+// SubMesh shares vertices of the mesh, its own indices allow hiding some of the quads.
 type SubMesh struct {
-	Model hmath.Matrix4
-	UVs   *VertexBuffer2f
+	Model   hmath.Matrix4
+	UVs     *VertexBuffer2f
+	Indices *IndexBuffer
 }
@@ -51,3 +51,84 @@ void main()
 { 
     gl_FragData[0] = texture2D(quad, vUV);
 }`
+
+// TileTextureVertexShaderSource draws a single quad per layer,
+// tile ids are looked up from integer texture in the fragment shader.
+const TileTextureVertexShaderSource = `#version 300 es
+in vec3 position;
+
+out vec2 vTile;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform vec2 tileSize;
+
+void main(void) {
+  gl_Position = projection * view * model * vec4(position, 1.0);
+  vTile = position.xy / tileSize;
+}
+`
+
+const TileTextureFragmentShaderSource = `#version 300 es
+precision highp float;
+precision highp int;
+precision highp isampler2D;
+
+in vec2 vTile;
+
+out vec4 color;
+
+uniform sampler2D tileset;
+// tile id per texel, row 0 is the top row of the map (as in layer data)
+uniform isampler2D tiles;
+uniform vec2 mapSize;
+uniform vec2 tileSize;
+// tileset layout, see hashira.TilesetLayout
+uniform int tilesetMargin;
+uniform int tilesetSpacing;
+uniform int tilesetColumns;
+uniform int tileCount;
+// atlas position (x | y << 16) of every tile when tileset is packed, 1024 tiles per row
+uniform isampler2D regions;
+uniform int useRegions;
+
+void main(void) {
+  ivec2 cell = ivec2(floor(vTile));
+  int value = texelFetch(tiles, ivec2(cell.x, int(mapSize.y) - cell.y - 1), 0).r;
+  // empty tile, see hashira.EmptyTile
+  if (value < 0) {
+    discard;
+  }
+  // flip flags in high bits, see hashira.TileFlipHorizontal
+  int tile = value & 0x0FFFFFFF;
+  if (tile >= tileCount) {
+    discard;
+  }
+
+  ivec2 size = ivec2(tileSize);
+  ivec2 origin;
+  if (useRegions != 0) {
+    int region = texelFetch(regions, ivec2(tile % 1024, tile / 1024), 0).r;
+    origin = ivec2(region & 0xFFFF, region >> 16);
+  } else {
+    origin = tilesetMargin + ivec2(tile % tilesetColumns, tile / tilesetColumns) * (size + tilesetSpacing);
+  }
+  // tileset rows grow down, map rows grow up
+  vec2 local = fract(vTile);
+  vec2 st = vec2(local.x, 1.0 - local.y);
+  if ((value & 0x40000000) != 0) {
+    st.x = 1.0 - st.x;
+  }
+  if ((value & 0x20000000) != 0) {
+    st.y = 1.0 - st.y;
+  }
+  if ((value & 0x10000000) != 0) {
+    st = st.yx;
+  }
+  ivec2 texel = ivec2(floor(st * tileSize));
+  texel = clamp(texel, ivec2(0), size - 1);
+
+  color = texelFetch(tileset, origin + texel, 0);
+}
+`
@@ -0,0 +1,48 @@
+package hgl
+
+import "fmt"
+
+type TextureFilter string
+
+const (
+	// FilterNearest keeps pixel art sharp.
+	FilterNearest TextureFilter = "nearest"
+	FilterLinear  TextureFilter = "linear"
+)
+
+type TextureWrap string
+
+const (
+	WrapClamp  TextureWrap = "clamp"
+	WrapRepeat TextureWrap = "repeat"
+	WrapMirror TextureWrap = "mirror"
+)
+
+// TextureOptions control sampling of tileset textures, zero value is nearest filtering
+// without mipmaps clamped to edges.
+type TextureOptions struct {
+	Filter TextureFilter `json:"filter,omitempty"`
+	// Mipmaps are generated on upload and sampled when zoomed out,
+	// tiles should be packed with extrusion (see BuildAtlas) so smaller levels don't bleed.
+	Mipmaps bool        `json:"mipmaps,omitempty"`
+	Wrap    TextureWrap `json:"wrap,omitempty"`
+}
+
+func (o TextureOptions) Validate() error {
+	switch o.Filter {
+	case "", FilterNearest, FilterLinear:
+	default:
+		return fmt.Errorf("unknown texture filter: %v", o.Filter)
+	}
+	switch o.Wrap {
+	case "", WrapClamp, WrapRepeat, WrapMirror:
+	default:
+		return fmt.Errorf("unknown texture wrap: %v", o.Wrap)
+	}
+	return nil
+}
+
+// Linear reports whether texels are blended.
+func (o TextureOptions) Linear() bool {
+	return o.Filter == FilterLinear
+}
@@ -17,14 +17,24 @@ func (v *VertexBuffer3f) Len() int {
 
 func (v *VertexBuffer3f) At(i int) (x, y, z float32) {
 	i *= 3
-	return v.data.Data[i], v.data.Data[i+1], v.data.Data[i+2]
+	return v.data.At(i), v.data.At(i + 1), v.data.At(i + 2)
 }
 
 func (v *VertexBuffer3f) Set(i int, x, y, z float32) {
 	i *= 3
-	v.data.Data[i] = x
-	v.data.Data[i+1] = y
-	v.data.Data[i+2] = z
+	v.data.Set(i, x)
+	v.data.Set(i+1, y)
+	v.data.Set(i+2, z)
+}
+
+// SetQuad sets positions of quad at index i: bottom left, bottom right, top left, top right.
+func (v *VertexBuffer3f) SetQuad(i int, x0, y0, x1, y1, z float32) {
+	i *= VerticesPerQuad
+
+	v.Set(i+0, x0, y0, z)
+	v.Set(i+1, x1, y0, z)
+	v.Set(i+2, x0, y1, z)
+	v.Set(i+3, x1, y1, z)
 }
 
 func (v *VertexBuffer3f) Data() *Float32ArrayBuffer {
@@ -48,25 +58,50 @@ func (v *VertexBuffer2f) Len() int {
 
 func (v *VertexBuffer2f) At(i int) (x, y float32) {
 	i *= 2
-	return v.data.Data[i], v.data.Data[i+1]
+	return v.data.At(i), v.data.At(i + 1)
 }
 
 func (v *VertexBuffer2f) Set(i int, x, y float32) {
 	i *= 2
-	v.data.Data[i] = x
-	v.data.Data[i+1] = y
+	v.data.Set(i, x)
+	v.data.Set(i+1, y)
 }
 
+// QuadFlip mirrors texture of a quad, diagonal flip (swapping axes) is applied first,
+// then horizontal and vertical flips (same as in Tiled).
+type QuadFlip uint8
+
+const (
+	FlipHorizontal QuadFlip = 1 << iota
+	FlipVertical
+	FlipDiagonal
+)
+
+// SetQuad sets UVs of quad at index i in the same order as VertexBuffer3f.SetQuad,
+// v0 is the top of the texture.
 func (v *VertexBuffer2f) SetQuad(i int, u0, v0, u1, v1 float32) {
-	i *= 6
+	v.SetQuadFlipped(i, u0, v0, u1, v1, 0)
+}
 
-	v.Set(i+0, u0, v1)
-	v.Set(i+1, u1, v1)
-	v.Set(i+2, u1, v0)
+// SetQuadFlipped sets UVs of quad at index i with corners permuted by flip.
+func (v *VertexBuffer2f) SetQuadFlipped(i int, u0, v0, u1, v1 float32, flip QuadFlip) {
+	i *= VerticesPerQuad
 
-	v.Set(i+3, u1, v0)
-	v.Set(i+4, u0, v0)
-	v.Set(i+5, u0, v1)
+	// corner positions (s right, t down) in the same order as vertices
+	corners := [VerticesPerQuad][2]float32{{0, 1}, {1, 1}, {0, 0}, {1, 0}}
+	for k, c := range corners {
+		s, t := c[0], c[1]
+		if flip&FlipHorizontal != 0 {
+			s = 1 - s
+		}
+		if flip&FlipVertical != 0 {
+			t = 1 - t
+		}
+		if flip&FlipDiagonal != 0 {
+			s, t = t, s
+		}
+		v.Set(i+k, u0+s*(u1-u0), v0+t*(v1-v0))
+	}
 }
 
 func (v *VertexBuffer2f) Data() *Float32ArrayBuffer {
@@ -0,0 +1,89 @@
+package hgl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVertexBuffer2fSetQuad(t *testing.T) {
+	uvs := NewVertexBuffer2f(12)
+
+	uvs.SetQuad(1, 0.1, 0.2, 0.3, 0.4)
+
+	want := [][2]float32{{0.1, 0.4}, {0.3, 0.4}, {0.1, 0.2}, {0.3, 0.2}}
+	for k, uv := range want {
+		u, v := uvs.At(4 + k)
+		if u != uv[0] || v != uv[1] {
+			t.Errorf("uv %d: got (%v, %v), want %v", k, u, v, uv)
+		}
+	}
+	if u, v := uvs.At(0); u != 0 || v != 0 {
+		t.Errorf("first quad should not be modified, got (%v, %v)", u, v)
+	}
+}
+
+func TestVertexBuffer2fSetQuadFlipped(t *testing.T) {
+	tests := []struct {
+		flip QuadFlip
+		want [][2]float32
+	}{
+		{FlipHorizontal, [][2]float32{{0.3, 0.4}, {0.1, 0.4}, {0.3, 0.2}, {0.1, 0.2}}},
+		{FlipVertical, [][2]float32{{0.1, 0.2}, {0.3, 0.2}, {0.1, 0.4}, {0.3, 0.4}}},
+		{FlipDiagonal, [][2]float32{{0.3, 0.2}, {0.3, 0.4}, {0.1, 0.2}, {0.1, 0.4}}},
+		// rotated 90 degrees clockwise, bottom left of the texture ends up at the top left
+		{FlipHorizontal | FlipDiagonal, [][2]float32{{0.3, 0.4}, {0.3, 0.2}, {0.1, 0.4}, {0.1, 0.2}}},
+	}
+
+	for _, test := range tests {
+		uvs := NewVertexBuffer2f(4)
+		uvs.SetQuadFlipped(0, 0.1, 0.2, 0.3, 0.4, test.flip)
+
+		for k, uv := range test.want {
+			u, v := uvs.At(k)
+			if u != uv[0] || v != uv[1] {
+				t.Errorf("flip %b uv %d: got (%v, %v), want %v", test.flip, k, u, v, uv)
+			}
+		}
+	}
+}
+
+func TestNewQuadIndexBuffer(t *testing.T) {
+	ib := NewQuadIndexBuffer(2)
+
+	want := []uint32{0, 1, 2, 2, 1, 3, 4, 5, 6, 6, 5, 7}
+	if got := ib.Data().data; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIndexBufferHideQuad(t *testing.T) {
+	ib := NewQuadIndexBuffer(2)
+	ib.Data().ClearDirty()
+
+	ib.HideQuad(1)
+	if !ib.QuadHidden(1) || ib.QuadHidden(0) {
+		t.Errorf("got hidden %v %v, want only quad 1 hidden", ib.QuadHidden(0), ib.QuadHidden(1))
+	}
+	if got, want := ib.Data().Dirty(), (DirtyRange{From: 6, To: 12}); got != want {
+		t.Errorf("got dirty %v, want %v", got, want)
+	}
+
+	ib.ShowQuad(1)
+	want := []uint32{0, 1, 2, 2, 1, 3, 4, 5, 6, 6, 5, 7}
+	if got := ib.Data().data; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFloat32ArrayBufferBytes(t *testing.T) {
+	buf := NewFloat32ArrayBuffer([]float32{1, -2})
+
+	want := []byte{0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0xc0}
+	got := buf.Bytes()
+	if string(got) != string(want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if buf.Len() != 2 {
+		t.Errorf("got len %d, want 2", buf.Len())
+	}
+}
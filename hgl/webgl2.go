@@ -1,3 +1,5 @@
+//go:build js && wasm
+
 package hgl
 
 // Goal:
@@ -63,9 +65,11 @@ type WebGL struct {
 	gl     hjs.WebGL2RenderingContext
 	Canvas hjs.Canvas
 
-	Texture2D TextureType
-	RGBA      PixelFormat
-	RGB       PixelFormat
+	Texture2D  TextureType
+	RGBA       PixelFormat
+	RGB        PixelFormat
+	R32I       PixelFormat
+	RedInteger PixelFormat
 
 	StaticDraw            BufferUsage
 	DynamicDraw           BufferUsage
@@ -86,12 +90,14 @@ type WebGL struct {
 	Float        Type
 	UnsignedByte Type
 	UnsignedInt  Type
+	Int          Type
 
 	VertexShader   ShaderType
 	FragmentShader ShaderType
 
-	DepthTest Capability
-	Blend     Capability
+	DepthTest   Capability
+	Blend       Capability
+	ScissorTest Capability
 
 	ColorBufferBit   BufferMask
 	DepthBufferBit   BufferMask
@@ -120,6 +126,14 @@ type WebGL struct {
 	Linear           TextureParameter
 	ClampToEdge      TextureParameter
 
+	// mipmap filters are valid for TextureMinFilter only
+	NearestMipmapNearest TextureParameter
+	NearestMipmapLinear  TextureParameter
+	LinearMipmapNearest  TextureParameter
+	LinearMipmapLinear   TextureParameter
+	Repeat               TextureParameter
+	MirroredRepeat       TextureParameter
+
 	CompileStatus                          ShaderParameter
 	LinkStatus                             ProgramParameter
 	ValidateStatus                         ProgramParameter
@@ -151,9 +165,11 @@ func NewWebGL(canvas hjs.Canvas) (*WebGL, error) {
 		Canvas: canvas,
 		gl:     gl,
 
-		Texture2D: TextureType(gl.GetInt("TEXTURE_2D")),
-		RGBA:      PixelFormat(gl.GetInt("RGBA")),
-		RGB:       PixelFormat(gl.GetInt("RGB")),
+		Texture2D:  TextureType(gl.GetInt("TEXTURE_2D")),
+		RGBA:       PixelFormat(gl.GetInt("RGBA")),
+		RGB:        PixelFormat(gl.GetInt("RGB")),
+		R32I:       PixelFormat(gl.GetInt("R32I")),
+		RedInteger: PixelFormat(gl.GetInt("RED_INTEGER")),
 
 		StaticDraw:         BufferUsage(gl.GetInt("STATIC_DRAW")),
 		DynamicDraw:        BufferUsage(gl.GetInt("DYNAMIC_DRAW")),
@@ -172,12 +188,14 @@ func NewWebGL(canvas hjs.Canvas) (*WebGL, error) {
 		Float:        Type(gl.GetInt("FLOAT")),
 		UnsignedByte: Type(gl.GetInt("UNSIGNED_BYTE")),
 		UnsignedInt:  Type(gl.GetInt("UNSIGNED_INT")),
+		Int:          Type(gl.GetInt("INT")),
 
 		VertexShader:   ShaderType(gl.GetInt("VERTEX_SHADER")),
 		FragmentShader: ShaderType(gl.GetInt("FRAGMENT_SHADER")),
 
-		DepthTest: Capability(gl.GetInt("DEPTH_TEST")),
-		Blend:     Capability(gl.GetInt("BLEND")),
+		DepthTest:   Capability(gl.GetInt("DEPTH_TEST")),
+		Blend:       Capability(gl.GetInt("BLEND")),
+		ScissorTest: Capability(gl.GetInt("SCISSOR_TEST")),
 
 		ColorBufferBit:   BufferMask(gl.GetInt("COLOR_BUFFER_BIT")),
 		DepthBufferBit:   BufferMask(gl.GetInt("DEPTH_BUFFER_BIT")),
@@ -204,6 +222,13 @@ func NewWebGL(canvas hjs.Canvas) (*WebGL, error) {
 		Linear:           TextureParameter(gl.GetInt("LINEAR")),
 		ClampToEdge:      TextureParameter(gl.GetInt("CLAMP_TO_EDGE")),
 
+		NearestMipmapNearest: TextureParameter(gl.GetInt("NEAREST_MIPMAP_NEAREST")),
+		NearestMipmapLinear:  TextureParameter(gl.GetInt("NEAREST_MIPMAP_LINEAR")),
+		LinearMipmapNearest:  TextureParameter(gl.GetInt("LINEAR_MIPMAP_NEAREST")),
+		LinearMipmapLinear:   TextureParameter(gl.GetInt("LINEAR_MIPMAP_LINEAR")),
+		Repeat:               TextureParameter(gl.GetInt("REPEAT")),
+		MirroredRepeat:       TextureParameter(gl.GetInt("MIRRORED_REPEAT")),
+
 		CompileStatus:                          ShaderParameter(gl.GetInt("COMPILE_STATUS")),
 		LinkStatus:                             ProgramParameter(gl.GetInt("LINK_STATUS")),
 		ValidateStatus:                         ProgramParameter(gl.GetInt("VALIDATE_STATUS")),
@@ -254,10 +279,19 @@ func (w *WebGL) Viewport(x, y, width, height int) {
 	w.gl.Call("viewport", x, y, width, height)
 }
 
+// Scissor sets rectangle with origin at bottom left, used when ScissorTest is enabled.
+func (w *WebGL) Scissor(x, y, width, height int) {
+	w.gl.Call("scissor", x, y, width, height)
+}
+
 func (w *WebGL) CreateFramebuffer() Framebuffer {
 	return Framebuffer(w.gl.Call("createFramebuffer"))
 }
 
+func (w *WebGL) DeleteFramebuffer(framebuffer Framebuffer) {
+	w.gl.Call("deleteFramebuffer", js.Value(framebuffer))
+}
+
 func (w *WebGL) BindFramebuffer(target FramebufferTarget, framebuffer Framebuffer) {
 	w.gl.Call("bindFramebuffer", int(target), js.Value(framebuffer))
 }
@@ -274,6 +308,10 @@ func (w *WebGL) CreateVertexArray() VertexArrayObject {
 	return VertexArrayObject(w.gl.Call("createVertexArray"))
 }
 
+func (w *WebGL) DeleteVertexArray(vao VertexArrayObject) {
+	w.gl.Call("deleteVertexArray", js.Value(vao))
+}
+
 func (w *WebGL) CreateTexture() Texture {
 	tex := w.gl.Call("createTexture")
 	return Texture(&tex)
@@ -303,10 +341,19 @@ func (gl *WebGL) TexParameteri(texType TextureType, name TextureParameterName, p
 	gl.gl.Call("texParameteri", int(texType), int(name), int(param))
 }
 
+// GenerateMipmap builds all mipmap levels of the bound texture from level 0.
+func (gl *WebGL) GenerateMipmap(texType TextureType) {
+	gl.gl.Call("generateMipmap", int(texType))
+}
+
 func (w *WebGL) CreateProgram() Program {
 	return Program(w.gl.Call("createProgram"))
 }
 
+func (w *WebGL) DeleteProgram(program Program) {
+	w.gl.Call("deleteProgram", js.Value(program))
+}
+
 func (w *WebGL) UseProgram(program Program) {
 	w.gl.Call("useProgram", js.Value(program))
 }
@@ -391,6 +438,10 @@ func (w *WebGL) Uniform1Int(location Location, value int) {
 	w.gl.Call("uniform1i", js.Value(location), value)
 }
 
+func (w *WebGL) Uniform2f(location Location, x, y float32) {
+	w.gl.Call("uniform2f", js.Value(location), x, y)
+}
+
 func (w *WebGL) TexImage2DRGBA(width int, height int, data []byte) {
 	pixels := hjs.NewUInt8Array(data)
 	w.gl.Call(
@@ -407,6 +458,38 @@ func (w *WebGL) TexImage2DRGBA(width int, height int, data []byte) {
 	)
 }
 
+// TexImage2DR32I uploads single channel integer texture, sampled with isampler2D.
+func (w *WebGL) TexImage2DR32I(width int, height int, data []int32) {
+	w.gl.Call(
+		"texImage2D",
+		int(w.Texture2D),
+		0, /*mipmap level*/
+		int(w.R32I),
+		width,
+		height,
+		0, /*border*/
+		int(w.RedInteger),
+		int(w.Int),
+		hjs.NewInt32Array(data),
+	)
+}
+
+// TexSubImage2DR32I replaces rectangle of integer texture, data is row major.
+func (w *WebGL) TexSubImage2DR32I(x int, y int, width int, height int, data []int32) {
+	w.gl.Call(
+		"texSubImage2D",
+		int(w.Texture2D),
+		0, /*mipmap level*/
+		x,
+		y,
+		width,
+		height,
+		int(w.RedInteger),
+		int(w.Int),
+		hjs.NewInt32Array(data),
+	)
+}
+
 func (w *WebGL) TexImage2DRGB(width int, height int, data []byte) {
 	pixels := hjs.NewUInt8Array(data)
 	w.gl.Call(
@@ -423,10 +506,21 @@ func (w *WebGL) TexImage2DRGB(width int, height int, data []byte) {
 	)
 }
 
+// ReadPixelsRGBA reads pixels from currently bound framebuffer, rows start at the bottom.
+func (w *WebGL) ReadPixelsRGBA(x, y, width, height int) []byte {
+	pixels := js.Global().Get("Uint8Array").New(width * height * 4)
+	w.gl.Call("readPixels", x, y, width, height, int(w.RGBA), int(w.UnsignedByte), pixels)
+	return hjs.CopyBytes(pixels)
+}
+
 func (w *WebGL) CreateBuffer() Buffer {
 	return Buffer(w.gl.Call("createBuffer"))
 }
 
+func (w *WebGL) DeleteBuffer(buffer Buffer) {
+	w.gl.Call("deleteBuffer", js.Value(buffer))
+}
+
 func (w *WebGL) EnableVertexAttribArray(location AttribLocation) {
 	w.gl.Call("enableVertexAttribArray", uint32(location))
 }
@@ -440,12 +534,16 @@ func (w *WebGL) BindBuffer(target BufferType, buffer Buffer) {
 }
 
 func (w *WebGL) BufferData(target BufferType, data BufferData, usage BufferUsage) {
-	b := data.Bytes()
-	dataJS := hjs.NewUInt8Array(b)
-	js.CopyBytesToJS(dataJS, b)
+	dataJS := hjs.NewUInt8Array(data.Bytes())
 	w.gl.Call("bufferData", int(target), dataJS, int(usage))
 }
 
+// BufferSubData replaces part of the bound buffer starting at offset in bytes.
+func (w *WebGL) BufferSubData(target BufferType, offset int, data []byte) {
+	dataJS := hjs.NewUInt8Array(data)
+	w.gl.Call("bufferSubData", int(target), offset, dataJS)
+}
+
 func (w *WebGL) DrawArrays(mode DrawMode, first int, count int) {
 	w.gl.Call("drawArrays", int(mode), first, count)
 }
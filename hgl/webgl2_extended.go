@@ -1,8 +1,11 @@
+//go:build js && wasm
+
 package hgl
 
 import (
 	"errors"
 	"fmt"
+	"image"
 	"syscall/js"
 )
 
@@ -79,6 +82,31 @@ func (fbo *FBO) Resize(w *WebGLExtended, screen Screen) {
 	w.BindFramebuffer(w.Framebuffer, w.FramebufferNone)
 }
 
+// Delete releases all GL objects owned by the FBO.
+func (fbo *FBO) Delete(w *WebGLExtended) {
+	w.DeleteFramebuffer(fbo.Framebuffer)
+	w.DeleteTexture(fbo.Texture)
+	w.DeleteBuffer(fbo.VertexBuffer)
+	w.DeleteBuffer(fbo.UVBuffer)
+	w.DeleteVertexArray(fbo.VAO)
+	w.DeleteProgram(fbo.Program)
+}
+
+// ReadImage reads back FBO content as top-down image.
+func (fbo *FBO) ReadImage(w *WebGLExtended) *image.RGBA {
+	w.BindFramebuffer(w.Framebuffer, fbo.Framebuffer)
+	pixels := w.ReadPixelsRGBA(0, 0, fbo.Width, fbo.Height)
+	w.BindFramebuffer(w.Framebuffer, w.FramebufferNone)
+
+	img := image.NewRGBA(image.Rect(0, 0, fbo.Width, fbo.Height))
+	stride := fbo.Width * 4
+	for y := 0; y < fbo.Height; y++ {
+		src := (fbo.Height - y - 1) * stride
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], pixels[src:src+stride])
+	}
+	return img
+}
+
 func (fbo *FBO) Draw(w *WebGLExtended) {
 	w.Disable(w.DepthTest)
 	w.ActiveTexture(w.Texture0)
@@ -92,13 +120,62 @@ func (fbo *FBO) Draw(w *WebGLExtended) {
 }
 
 func (w *WebGLExtended) CreateDefaultTextureRGBA(img *Image) Texture {
+	return w.CreateTextureRGBA(img, TextureOptions{})
+}
+
+func (w *WebGLExtended) CreateTextureRGBA(img *Image, options TextureOptions) Texture {
+	texture := w.CreateTexture()
+	w.BindTexture(w.Texture2D, texture)
+	w.TexImage2DRGBA(img.Width, img.Height, img.Pixels())
+	w.applyTextureOptions(options)
+	w.BindTexture2D(nil)
+	return texture
+}
+
+// SetTextureOptions changes sampling of the texture, mipmaps are generated when enabled.
+func (w *WebGLExtended) SetTextureOptions(texture Texture, options TextureOptions) {
+	w.BindTexture(w.Texture2D, texture)
+	w.applyTextureOptions(options)
+	w.BindTexture2D(nil)
+}
+
+func (w *WebGLExtended) applyTextureOptions(options TextureOptions) {
+	wrap := w.ClampToEdge
+	switch options.Wrap {
+	case WrapRepeat:
+		wrap = w.Repeat
+	case WrapMirror:
+		wrap = w.MirroredRepeat
+	}
+	w.TexParameteri(w.Texture2D, w.TextureWrapS, wrap)
+	w.TexParameteri(w.Texture2D, w.TextureWrapT, wrap)
+
+	mag, min := w.Nearest, w.Nearest
+	if options.Linear() {
+		mag, min = w.Linear, w.Linear
+	}
+	if options.Mipmaps {
+		// levels are always blended, otherwise switching between them is visible while zooming
+		min = w.NearestMipmapLinear
+		if options.Linear() {
+			min = w.LinearMipmapLinear
+		}
+		w.GenerateMipmap(w.Texture2D)
+	}
+	w.TexParameteri(w.Texture2D, w.TextureMagFilter, mag)
+	w.TexParameteri(w.Texture2D, w.TextureMinFilter, min)
+}
+
+// CreateTileIndexTexture creates integer texture with one tile id per texel.
+func (w *WebGLExtended) CreateTileIndexTexture(width int, height int, data []int32) Texture {
 	texture := w.CreateTexture()
 	w.BindTexture(w.Texture2D, texture)
 	w.TexParameteri(w.Texture2D, w.TextureWrapS, w.ClampToEdge)
 	w.TexParameteri(w.Texture2D, w.TextureWrapT, w.ClampToEdge)
+	// integer textures can't be filtered
 	w.TexParameteri(w.Texture2D, w.TextureMagFilter, w.Nearest)
 	w.TexParameteri(w.Texture2D, w.TextureMinFilter, w.Nearest)
-	w.TexImage2DRGBA(img.Width, img.Height, img.Pixels())
+	w.TexImage2DR32I(width, height, data)
 	w.BindTexture2D(nil)
 	return texture
 }
@@ -162,12 +239,35 @@ func (w *WebGLExtended) EnableTransparency() {
 	w.BlendFunc(w.SrcAlpha, w.OneMinusSrcAlpha)
 }
 
+// BufferDataF uploads the whole buffer and clears its dirty range.
 func (w *WebGLExtended) BufferDataF(target BufferType, data *Float32ArrayBuffer, usage BufferUsage) {
 	w.BufferData(target, data, usage)
+	data.ClearDirty()
+}
+
+// BufferDirtyDataF uploads elements changed since last upload to the same position in the bound buffer.
+func (w *WebGLExtended) BufferDirtyDataF(target BufferType, data *Float32ArrayBuffer) {
+	dirty := data.Dirty()
+	if dirty.Empty() {
+		return
+	}
+	w.BufferSubData(target, dirty.From*4, data.BytesRange(dirty.From, dirty.To))
+	data.ClearDirty()
 }
 
 func (w *WebGLExtended) BufferDataU(target BufferType, data *UInt32ArrayBuffer, usage BufferUsage) {
 	w.BufferData(target, data, usage)
+	data.ClearDirty()
+}
+
+// BufferDirtyDataU uploads elements changed since last upload, see BufferDirtyDataF.
+func (w *WebGLExtended) BufferDirtyDataU(target BufferType, data *UInt32ArrayBuffer) {
+	dirty := data.Dirty()
+	if dirty.Empty() {
+		return
+	}
+	w.BufferSubData(target, dirty.From*4, data.BytesRange(dirty.From, dirty.To))
+	data.ClearDirty()
 }
 
 func (w *WebGLExtended) DrawTriangles(offset int, count int) {
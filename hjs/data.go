@@ -1,6 +1,9 @@
+//go:build js && wasm
+
 package hjs
 
 import (
+	"encoding/binary"
 	"syscall/js"
 )
 
@@ -12,8 +15,22 @@ func NewUInt8Array(array []byte) js.Value {
 	return jsArray
 }
 
+// NewInt32Array copies array to JS as little endian (WebAssembly is always little endian).
+func NewInt32Array(array []int32) js.Value {
+	b := make([]byte, len(array)*4)
+	for i, v := range array {
+		binary.LittleEndian.PutUint32(b[i*4:], uint32(v))
+	}
+	return js.Global().Get("Int32Array").New(NewUInt8Array(b).Get("buffer"))
+}
+
 type Object js.Value
 
+func (o Object) Has(key string) bool {
+	v := js.Value(o).Get(key)
+	return !v.IsUndefined() && !v.IsNull()
+}
+
 func (o Object) GetBool(key string) bool {
 	return js.Value(o).Get(key).Bool()
 }
@@ -47,7 +64,11 @@ func (o Object) GetIntArrayOfIntArray(key string) [][]int {
 }
 
 func (o Object) GetBytes(key string) []byte {
-	buffer := js.Value(o).Get(key)
+	return CopyBytes(js.Value(o).Get(key))
+}
+
+// CopyBytes copies Uint8Array (or Uint8ClampedArray) to Go.
+func CopyBytes(buffer js.Value) []byte {
 	b := make([]byte, buffer.Length())
 	js.CopyBytesToGo(b, buffer)
 	return b
@@ -0,0 +1,41 @@
+package hmath
+
+// Easing maps linear progress t in [0, 1] to eased progress.
+type Easing func(t float32) float32
+
+// Easings by name as used in events.
+var Easings = map[string]Easing{
+	"linear":    EaseLinear,
+	"inQuad":    EaseInQuad,
+	"outQuad":   EaseOutQuad,
+	"inOutQuad": EaseInOutQuad,
+	"outCubic":  EaseOutCubic,
+}
+
+func EaseLinear(t float32) float32 {
+	return t
+}
+
+func EaseInQuad(t float32) float32 {
+	return t * t
+}
+
+func EaseOutQuad(t float32) float32 {
+	return t * (2 - t)
+}
+
+func EaseInOutQuad(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+func EaseOutCubic(t float32) float32 {
+	u := t - 1
+	return u*u*u + 1
+}
+
+func Lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
@@ -2,7 +2,6 @@ package hmath
 
 import (
 	"math"
-	"syscall/js"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -30,13 +29,22 @@ func (m Matrix4) Floats() [16]float32 {
 	}
 }
 
-func (m Matrix4) JsValue() js.Value {
-	return js.Global().Get("Float32Array").Call("of",
-		m.Raw[0], m.Raw[1], m.Raw[2], m.Raw[3],
-		m.Raw[4], m.Raw[5], m.Raw[6], m.Raw[7],
-		m.Raw[8], m.Raw[9], m.Raw[10], m.Raw[11],
-		m.Raw[12], m.Raw[13], m.Raw[14], m.Raw[15],
-	)
+func (m Matrix4) Mul(other Matrix4) Matrix4 {
+	return Matrix4{m.Raw.Mul4(other.Raw)}
+}
+
+// Inverse returns zero matrix when matrix is not invertible.
+func (m Matrix4) Inverse() Matrix4 {
+	return Matrix4{m.Raw.Inv()}
+}
+
+// TransformPoint multiplies point (w=1) by the matrix and applies perspective division.
+func (m Matrix4) TransformPoint(v Vertex) Vertex {
+	r := m.Raw.Mul4x1(mgl32.Vec4{v[0], v[1], v[2], 1})
+	if r[3] == 0 {
+		return Vertex{r[0], r[1], r[2]}
+	}
+	return Vertex{r[0] / r[3], r[1] / r[3], r[2] / r[3]}
 }
 
 func Ortho(left, right, bottom, top float32, zNear, zFar float32) Matrix4 {
@@ -0,0 +1,14 @@
+//go:build js && wasm
+
+package hmath
+
+import "syscall/js"
+
+func (m Matrix4) JsValue() js.Value {
+	return js.Global().Get("Float32Array").Call("of",
+		m.Raw[0], m.Raw[1], m.Raw[2], m.Raw[3],
+		m.Raw[4], m.Raw[5], m.Raw[6], m.Raw[7],
+		m.Raw[8], m.Raw[9], m.Raw[10], m.Raw[11],
+		m.Raw[12], m.Raw[13], m.Raw[14], m.Raw[15],
+	)
+}
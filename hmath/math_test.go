@@ -0,0 +1,54 @@
+package hmath
+
+import "testing"
+
+func TestMatrix4Inverse(t *testing.T) {
+	m := TranslationMatrix(Vertex{3, -4, 5}).Mul(Ortho(-10, 10, -5, 5, -100, 100))
+	p := Vertex{1, 2, 3}
+
+	got := m.Inverse().TransformPoint(m.TransformPoint(p))
+
+	for i := range p {
+		if !CloseTo(got[i], p[i], 0.0001) {
+			t.Fatalf("got %v, want %v", got, p)
+		}
+	}
+}
+
+func TestMatrix4InverseSingular(t *testing.T) {
+	var m Matrix4
+
+	if got := m.Inverse(); got != (Matrix4{}) {
+		t.Errorf("got %v, want zero matrix", got)
+	}
+}
+
+func TestClamp(t *testing.T) {
+	tests := []struct {
+		value, min, max, want float32
+	}{
+		{5, 0, 10, 5},
+		{-1, 0, 10, 0},
+		{11, 0, 10, 10},
+	}
+
+	for _, tt := range tests {
+		if got := Clamp(tt.value, tt.min, tt.max); got != tt.want {
+			t.Errorf("Clamp(%v, %v, %v) = %v, want %v", tt.value, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestEasings(t *testing.T) {
+	for name, ease := range Easings {
+		if got := ease(0); !CloseTo(got, 0, 0.0001) {
+			t.Errorf("%v(0): got %v, want 0", name, got)
+		}
+		if got := ease(1); !CloseTo(got, 1, 0.0001) {
+			t.Errorf("%v(1): got %v, want 1", name, got)
+		}
+	}
+	if got := EaseInOutQuad(0.5); !CloseTo(got, 0.5, 0.0001) {
+		t.Errorf("got %v, want 0.5 in the middle", got)
+	}
+}
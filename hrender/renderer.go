@@ -0,0 +1,57 @@
+// Package hrender draws hashira worlds.
+// WebGLRenderer is used in the browser, SoftwareRenderer draws the same frames on CPU
+// and works anywhere (tests, servers).
+package hrender
+
+import (
+	"image"
+
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+)
+
+type Renderer interface {
+	Init(screen *hgl.Screen) error
+	Resize(screen *hgl.Screen)
+	// LoadTexture uploads (or replaces) tileset image with given name.
+	LoadTexture(name string, img *hgl.Image, options hgl.TextureOptions)
+	// SetTextureOptions changes sampling of already loaded texture,
+	// ModeTileTexture fetches texels directly so filtering has no effect there.
+	SetTextureOptions(name string, options hgl.TextureOptions)
+	Render(scene *Scene)
+	// Screenshot returns copy of the last rendered frame.
+	Screenshot() *image.RGBA
+	Stats() Stats
+	// Shutdown releases all resources, renderer can't be used afterwards.
+	Shutdown()
+}
+
+// Scene is everything needed to draw a single frame.
+type Scene struct {
+	World      *hashira.World
+	Camera     *hashira.Camera2D
+	Screen     *hgl.Screen
+	Background hgl.Color
+}
+
+// letterboxColor fills the screen outside of pixel perfect camera viewport.
+var letterboxColor = hgl.Color{0, 0, 0, 1}
+
+// Mode selects how WebGLRenderer draws layers, SoftwareRenderer output is the same for all modes.
+type Mode string
+
+const (
+	// ModeMesh draws chunk meshes with UVs built on CPU.
+	ModeMesh Mode = "mesh"
+	// ModeTileTexture uploads tile ids of every layer as integer texture,
+	// fragment shader looks the tileset cell up per pixel.
+	ModeTileTexture Mode = "texture"
+)
+
+// Stats of the last rendered frame, chunks outside of the camera view are not drawn.
+type Stats struct {
+	// always 0 in ModeTileTexture
+	ChunksDrawn int
+	// tiles drawn summed over all layers
+	TilesDrawn int
+}
@@ -0,0 +1,320 @@
+package hrender
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/qbart/hashira/ds"
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+// SoftwareRenderer is a CPU rasterizer producing the same frames as WebGLRenderer:
+// nearest texture sampling of layer UVs, layers ordered by z, alpha blending (SRC_ALPHA, ONE_MINUS_SRC_ALPHA).
+type SoftwareRenderer struct {
+	frame    *image.RGBA
+	textures *ds.HashMap[string, *softwareTexture]
+	stats    Stats
+}
+
+type softwareTexture struct {
+	width  int
+	height int
+	// RGBA, same bytes as uploaded to WebGL
+	pixels []byte
+	// mipmaps are not supported
+	options hgl.TextureOptions
+}
+
+func NewSoftwareRenderer() *SoftwareRenderer {
+	return &SoftwareRenderer{
+		textures: ds.NewHashMap[string, *softwareTexture](),
+	}
+}
+
+func (r *SoftwareRenderer) Init(screen *hgl.Screen) error {
+	r.Resize(screen)
+	return nil
+}
+
+func (r *SoftwareRenderer) Resize(screen *hgl.Screen) {
+	r.frame = image.NewRGBA(image.Rect(0, 0, screen.Width, screen.Height))
+}
+
+func (r *SoftwareRenderer) LoadTexture(name string, img *hgl.Image, options hgl.TextureOptions) {
+	r.textures.Set(name, &softwareTexture{
+		width:   img.Width,
+		height:  img.Height,
+		pixels:  img.Pixels(),
+		options: options,
+	})
+}
+
+func (r *SoftwareRenderer) SetTextureOptions(name string, options hgl.TextureOptions) {
+	if r.textures.Has(name) {
+		r.textures.Get(name).options = options
+	}
+}
+
+// Image returns last rendered frame.
+func (r *SoftwareRenderer) Image() *image.RGBA {
+	return r.frame
+}
+
+func (r *SoftwareRenderer) Screenshot() *image.RGBA {
+	img := image.NewRGBA(r.frame.Bounds())
+	copy(img.Pix, r.frame.Pix)
+	return img
+}
+
+func (r *SoftwareRenderer) Render(scene *Scene) {
+	r.clear(scene.Background)
+
+	// camera transform is affine so world position changes linearly with pixel position,
+	// pixels are sampled at their centers
+	camera := scene.Camera
+	ox, oy := camera.ScreenToWorld(scene.Screen, 0.5, 0.5)
+	x1, y1 := camera.ScreenToWorld(scene.Screen, 1.5, 0.5)
+	x2, y2 := camera.ScreenToWorld(scene.Screen, 0.5, 1.5)
+	view := &softwareView{
+		camera: camera,
+		screen: scene.Screen,
+		ox:     ox,
+		oy:     oy,
+		dxx:    x1 - ox,
+		dxy:    y1 - oy,
+		dyx:    x2 - ox,
+		dyy:    y2 - oy,
+	}
+
+	r.stats = Stats{}
+	rect := camera.VisibleRect(scene.Screen)
+	names := scene.World.Maps.Keys()
+	sort.Strings(names)
+	for _, name := range names {
+		m := scene.World.Maps.Get(name)
+		visible := m.VisibleChunks(rect)
+		r.stats.ChunksDrawn += len(visible)
+		for _, i := range layersByZ(m) {
+			texture := scene.World.Resources.TextureName(m.SubMeshLayer(i).TilesetName())
+			if !r.textures.Has(texture) {
+				continue
+			}
+			for _, v := range visible {
+				r.drawChunk(view, m, v, i, r.textures.Get(texture))
+			}
+		}
+	}
+	r.letterbox(camera, scene.Screen)
+}
+
+func (r *SoftwareRenderer) Stats() Stats {
+	return r.stats
+}
+
+func (r *SoftwareRenderer) Shutdown() {
+	r.textures.Clear()
+	r.frame = nil
+}
+
+type softwareView struct {
+	camera *hashira.Camera2D
+	screen *hgl.Screen
+
+	ox, oy   float32
+	dxx, dxy float32
+	dyx, dyy float32
+}
+
+func (v *softwareView) world(px, py int) (float32, float32) {
+	x := float32(px)
+	y := float32(py)
+	return v.ox + x*v.dxx + y*v.dyx, v.oy + x*v.dxy + y*v.dyy
+}
+
+// pixels returns screen pixel range [x0, x1) x [y0, y1) covering world rectangle.
+func (v *softwareView) pixels(rect hashira.Rect) (x0, y0, x1, y1 int) {
+	ax, ay := v.camera.WorldToScreen(v.screen, rect.MinX, rect.MinY)
+	bx, by := v.camera.WorldToScreen(v.screen, rect.MaxX, rect.MaxY)
+	x0 = clampInt(int(math.Floor(math.Min(float64(ax), float64(bx)))), 0, v.screen.Width)
+	y0 = clampInt(int(math.Floor(math.Min(float64(ay), float64(by)))), 0, v.screen.Height)
+	x1 = clampInt(int(math.Ceil(math.Max(float64(ax), float64(bx)))), 0, v.screen.Width)
+	y1 = clampInt(int(math.Ceil(math.Max(float64(ay), float64(by)))), 0, v.screen.Height)
+	return x0, y0, x1, y1
+}
+
+// layersByZ returns submesh indices from the bottom most layer.
+func layersByZ(m *hashira.Map) []int {
+	indices := make([]int, len(m.SubMeshLayerNames))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return m.SubMeshLayer(indices[a]).Z < m.SubMeshLayer(indices[b]).Z
+	})
+	return indices
+}
+
+func (r *SoftwareRenderer) clear(c hgl.Color) {
+	b := colorBytes(c)
+	pix := r.frame.Pix
+	for i := 0; i < len(pix); i += 4 {
+		copy(pix[i:i+4], b[:])
+	}
+}
+
+// letterbox fills the screen outside of camera viewport.
+func (r *SoftwareRenderer) letterbox(camera *hashira.Camera2D, screen *hgl.Screen) {
+	x0, y0, w, h := camera.Viewport(screen)
+	x1, y1 := x0+w, y0+h
+	if x0 == 0 && y0 == 0 && x1 == screen.Width && y1 == screen.Height {
+		return
+	}
+	b := colorBytes(letterboxColor)
+	for y := 0; y < screen.Height; y++ {
+		for x := 0; x < screen.Width; x++ {
+			if x >= x0 && x < x1 && y >= y0 && y < y1 {
+				continue
+			}
+			i := r.frame.PixOffset(x, y)
+			copy(r.frame.Pix[i:i+4], b[:])
+		}
+	}
+}
+
+func colorBytes(c hgl.Color) [4]byte {
+	b := [4]byte{}
+	for i := range b {
+		b[i] = byte(math.Round(float64(hmath.Clamp01(c[i]) * 255)))
+	}
+	return b
+}
+
+// drawChunk draws visible rows of the chunk, only pixels with centers inside them are touched
+// so every pixel is drawn once per layer.
+func (r *SoftwareRenderer) drawChunk(view *softwareView, m *hashira.Map, v hashira.VisibleChunk, subMesh int, tex *softwareTexture) {
+	tw := float32(m.TileWidth)
+	th := float32(m.TileHeight)
+	uvs := v.Mesh.SubMeshes[subMesh].UVs
+	indices := v.Mesh.SubMeshes[subMesh].Indices
+	minRow := v.Y + v.FromRow
+	maxRow := v.Y + v.ToRow
+
+	from, to := v.Quads()
+	r.stats.TilesDrawn += to - from
+
+	x0, y0, x1, y1 := view.pixels(hashira.Rect{
+		MinX: float32(v.X) * tw,
+		MinY: float32(minRow) * th,
+		MaxX: float32(v.X+v.Width) * tw,
+		MaxY: float32(maxRow) * th,
+	})
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			wx, wy := view.world(px, py)
+			fx := wx / tw
+			fy := wy / th
+			x := int(math.Floor(float64(fx)))
+			row := int(math.Floor(float64(fy)))
+			if x < v.X || x >= v.X+v.Width || row < minRow || row >= maxRow {
+				continue
+			}
+
+			quad := v.Quad(x-v.X, row-v.Y)
+			if indices.QuadHidden(quad) {
+				continue
+			}
+			// corners are bottom left, bottom right, top left and top right, see VertexBuffer2f.SetQuad,
+			// interpolating all of them keeps flipped tiles intact
+			s := fx - float32(x)
+			t := fy - float32(row)
+			tu, tv := float32(0), float32(0)
+			weights := [hgl.VerticesPerQuad]float32{(1 - s) * (1 - t), s * (1 - t), (1 - s) * t, s * t}
+			for k, weight := range weights {
+				cu, cv := uvs.At(quad*hgl.VerticesPerQuad + k)
+				tu += cu * weight
+				tv += cv * weight
+			}
+
+			r.blend(px, py, tex.sample(tu, tv))
+		}
+	}
+}
+
+func (t *softwareTexture) sample(u, v float32) [4]byte {
+	fx := float64(u * float32(t.width))
+	fy := float64(v * float32(t.height))
+	if !t.options.Linear() {
+		return t.texel(int(math.Floor(fx)), int(math.Floor(fy)))
+	}
+
+	// bilinear, texel centers are at half pixels
+	fx -= 0.5
+	fy -= 0.5
+	x0 := int(math.Floor(fx))
+	y0 := int(math.Floor(fy))
+	ax := float32(fx) - float32(x0)
+	ay := float32(fy) - float32(y0)
+	c00 := t.texel(x0, y0)
+	c10 := t.texel(x0+1, y0)
+	c01 := t.texel(x0, y0+1)
+	c11 := t.texel(x0+1, y0+1)
+	var c [4]byte
+	for i := range c {
+		top := float32(c00[i])*(1-ax) + float32(c10[i])*ax
+		bottom := float32(c01[i])*(1-ax) + float32(c11[i])*ax
+		c[i] = byte(math.Round(float64(top*(1-ay) + bottom*ay)))
+	}
+	return c
+}
+
+// texel returns pixel at given texel coordinates, coordinates outside of the texture are wrapped.
+func (t *softwareTexture) texel(x, y int) [4]byte {
+	x = wrapTexel(x, t.width, t.options.Wrap)
+	y = wrapTexel(y, t.height, t.options.Wrap)
+	i := (y*t.width + x) * 4
+	return [4]byte{t.pixels[i], t.pixels[i+1], t.pixels[i+2], t.pixels[i+3]}
+}
+
+func wrapTexel(x, size int, wrap hgl.TextureWrap) int {
+	switch wrap {
+	case hgl.WrapRepeat:
+		x %= size
+		if x < 0 {
+			x += size
+		}
+		return x
+	case hgl.WrapMirror:
+		period := 2 * size
+		x %= period
+		if x < 0 {
+			x += period
+		}
+		if x >= size {
+			x = period - x - 1
+		}
+		return x
+	}
+	return clampInt(x, 0, size-1)
+}
+
+func (r *SoftwareRenderer) blend(px, py int, src [4]byte) {
+	i := r.frame.PixOffset(px, py)
+	dst := r.frame.Pix[i : i+4]
+	a := float32(src[3]) / 255
+	for c := 0; c < 4; c++ {
+		dst[c] = byte(math.Round(float64(float32(src[c])*a + float32(dst[c])*(1-a))))
+	}
+}
+
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
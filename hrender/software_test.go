@@ -0,0 +1,379 @@
+package hrender
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+)
+
+var update = flag.Bool("update", false, "update golden images")
+
+var testTileColors = []color.NRGBA{
+	{255, 0, 0, 255},
+	{0, 255, 0, 255},
+	{0, 0, 255, 255},
+	{255, 255, 0, 255},
+	{255, 255, 255, 255},
+	{0, 0, 0, 128},
+	{0, 0, 0, 0},
+	{255, 0, 255, 255},
+}
+
+// newTestTileset encodes 4x2 tileset of 4x4 px tiles,
+// top left texel of every opaque tile is darker to make orientation visible.
+func newTestTileset(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 8))
+	for tile, c := range testTileColors {
+		tx := (tile % 4) * 4
+		ty := (tile / 4) * 4
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				img.SetNRGBA(tx+x, ty+y, c)
+			}
+		}
+		if c.A == 255 {
+			img.SetNRGBA(tx, ty, color.NRGBA{c.R / 2, c.G / 2, c.B / 2, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestScene(t *testing.T, r *SoftwareRenderer, width, height int) *Scene {
+	t.Helper()
+	w := hashira.New()
+	img, err := w.Resources.LoadTileset(hashira.DefaultTileset, newTestTileset(t), hashira.TilesetLayout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.LoadTexture(hashira.DefaultTileset, img, hgl.TextureOptions{})
+
+	w.AddMap("main", 3, 2, 4, 4)
+	// added out of z order on purpose
+	w.AddLayer("main", "top", 1, "")
+	w.AddLayer("main", "ground", 0, "")
+	w.AddLayerData("main", "ground", [][]int{
+		{0, 1, 2},
+		{3, 4, 7},
+	})
+	w.AddLayerData("main", "top", [][]int{
+		{5, 6, 6},
+		{6, 6, 5},
+	})
+	w.Sync()
+
+	screen := &hgl.Screen{Width: width, Height: height, DevicePixelRatio: 1}
+	if err := r.Init(screen); err != nil {
+		t.Fatal(err)
+	}
+	return &Scene{
+		World:      w,
+		Camera:     hashira.NewCamera2D(),
+		Screen:     screen,
+		Background: hgl.Color{0.5, 0.5, 0.5, 1},
+	}
+}
+
+func TestSoftwareRenderer(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		setup  func(scene *Scene)
+	}{
+		{
+			name:   "layers",
+			width:  12,
+			height: 8,
+			setup: func(scene *Scene) {
+				scene.Camera.Translate(6, 4)
+			},
+		},
+		{
+			name:   "zoom",
+			width:  16,
+			height: 16,
+			setup: func(scene *Scene) {
+				scene.Camera.Translate(4, 4)
+				scene.Camera.SetZoom(2)
+			},
+		},
+		{
+			name:   "background",
+			width:  16,
+			height: 12,
+			setup: func(scene *Scene) {
+				scene.Camera.Translate(0, 0)
+				scene.Background = hgl.Color{0, 0.25, 0.5, 1}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSoftwareRenderer()
+			scene := newTestScene(t, r, tt.width, tt.height)
+			tt.setup(scene)
+			r.Render(scene)
+			assertGolden(t, tt.name, r.Image())
+		})
+	}
+}
+
+func TestSoftwareRendererSkipsMissingTexture(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 12, 8)
+	scene.Camera.Translate(6, 4)
+	r.Shutdown()
+	r.Init(scene.Screen)
+	r.Render(scene)
+
+	img := r.Image()
+	want := color.RGBA{128, 128, 128, 255}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 12; x++ {
+			if got := img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d): got %v, want background %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestSoftwareRendererOrientation(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 12, 8)
+	scene.Camera.Translate(6, 4)
+	r.Render(scene)
+	img := r.Image()
+
+	// data row 0 is drawn at the top, tile 1 (green) is covered only by transparent tile
+	if got, want := img.RGBAAt(5, 1), (color.RGBA{0, 255, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// darker texel marks top left corner of the tile
+	if got, want := img.RGBAAt(4, 0), (color.RGBA{0, 127, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// tile 0 (red) blended with half transparent black from the top layer
+	if got, want := img.RGBAAt(1, 1), (color.RGBA{127, 0, 0, 191}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSoftwareRendererFlippedTile(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 12, 8)
+	scene.Camera.Translate(6, 4)
+	scene.World.SetTile("main", "ground", 1, 0, 1|hashira.TileFlipHorizontal)
+	r.Render(scene)
+	img := r.Image()
+
+	// darker texel moves from the top left to the top right corner
+	if got, want := img.RGBAAt(4, 0), (color.RGBA{0, 255, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := img.RGBAAt(7, 0), (color.RGBA{0, 127, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSoftwareRendererEmptyTiles(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 12, 8)
+	scene.Camera.Translate(6, 4)
+	scene.World.SetTile("main", "top", 0, 0, hashira.EmptyTile)
+	scene.World.SetTile("main", "ground", 1, 0, hashira.EmptyTile)
+	r.Render(scene)
+	img := r.Image()
+
+	// empty top tile does not occlude red ground tile
+	if got, want := img.RGBAAt(1, 1), (color.RGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// nothing is drawn in empty cells of all layers
+	if got, want := img.RGBAAt(5, 1), (color.RGBA{128, 128, 128, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSoftwareRendererLetterbox(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 13, 9)
+	scene.Camera.SetPixelPerfect(true, 0)
+	scene.Camera.SetZoom(2)
+	scene.Camera.Translate(6, 4)
+	r.Render(scene)
+	img := r.Image()
+
+	// 6x4 texels fit, odd pixels at the right and bottom are letterboxed
+	black := color.RGBA{0, 0, 0, 255}
+	for y := 0; y < 9; y++ {
+		if got := img.RGBAAt(12, y); got != black {
+			t.Errorf("pixel (12, %d): got %v, want letterbox %v", y, got, black)
+		}
+	}
+	for x := 0; x < 13; x++ {
+		if got := img.RGBAAt(x, 8); got != black {
+			t.Errorf("pixel (%d, 8): got %v, want letterbox %v", x, got, black)
+		}
+	}
+	if got, want := img.RGBAAt(6, 3), (color.RGBA{0, 255, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSoftwareRendererAtlas(t *testing.T) {
+	r := NewSoftwareRenderer()
+	scene := newTestScene(t, r, 24, 16)
+	scene.Camera.Translate(6, 4)
+	scene.Camera.SetZoom(2)
+	r.Render(scene)
+	want := r.Screenshot()
+
+	img, err := scene.World.Resources.PackAtlas("atlas", 4, 4, 1, hashira.DefaultTileset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.LoadTexture("atlas", img, hgl.TextureOptions{})
+	scene.World.Resync()
+	scene.World.Sync()
+	r.Render(scene)
+
+	// same tiles are sampled from the atlas
+	if got := r.Image(); !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("atlas render differs from tileset render")
+	}
+}
+
+func TestSoftwareTextureFiltering(t *testing.T) {
+	// black and white texel in a single row
+	tex := &softwareTexture{width: 2, height: 1, pixels: []byte{0, 0, 0, 255, 255, 255, 255, 255}}
+
+	if got := tex.sample(0.49, 0.5); got != [4]byte{0, 0, 0, 255} {
+		t.Errorf("got nearest %v, want black", got)
+	}
+
+	tex.options = hgl.TextureOptions{Filter: hgl.FilterLinear}
+	// halfway between texel centers
+	if got := tex.sample(0.5, 0.5); got != [4]byte{128, 128, 128, 255} {
+		t.Errorf("got linear %v, want gray", got)
+	}
+	// edge is clamped by default
+	if got := tex.sample(0, 0.5); got != [4]byte{0, 0, 0, 255} {
+		t.Errorf("got clamped %v, want black", got)
+	}
+
+	tex.options.Wrap = hgl.WrapRepeat
+	if got := tex.sample(0, 0.5); got != [4]byte{128, 128, 128, 255} {
+		t.Errorf("got repeated %v, want gray", got)
+	}
+}
+
+func TestWrapTexel(t *testing.T) {
+	tests := []struct {
+		x    int
+		wrap hgl.TextureWrap
+		want int
+	}{
+		{-1, hgl.WrapClamp, 0},
+		{5, hgl.WrapClamp, 3},
+		{-1, hgl.WrapRepeat, 3},
+		{5, hgl.WrapRepeat, 1},
+		{-1, hgl.WrapMirror, 0},
+		{4, hgl.WrapMirror, 3},
+		{9, hgl.WrapMirror, 1},
+	}
+
+	for _, tt := range tests {
+		if got := wrapTexel(tt.x, 4, tt.wrap); got != tt.want {
+			t.Errorf("wrap %v of %d: got %d, want %d", tt.wrap, tt.x, got, tt.want)
+		}
+	}
+}
+
+func assertGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading golden image (run with -update to create): %v", err)
+	}
+	golden, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("got bounds %v, want %v", img.Bounds(), golden.Bounds())
+	}
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			got := img.RGBAAt(x, y)
+			want := color.RGBAModel.Convert(golden.At(x, y)).(color.RGBA)
+			if got != want {
+				t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestSoftwareRendererCulling(t *testing.T) {
+	r := NewSoftwareRenderer()
+	w := hashira.New()
+	img, err := w.Resources.LoadTileset(hashira.DefaultTileset, newTestTileset(t), hashira.TilesetLayout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.LoadTexture(hashira.DefaultTileset, img, hgl.TextureOptions{})
+	w.AddMap("big", 200, 200, 4, 4)
+	w.AddLayer("big", "ground", 0, "")
+	data := make([][]int, 200)
+	for y := range data {
+		data[y] = make([]int, 200)
+	}
+	data[99][100] = 1
+	w.AddLayerData("big", "ground", data)
+	w.Sync()
+
+	screen := &hgl.Screen{Width: 16, Height: 16, DevicePixelRatio: 1}
+	if err := r.Init(screen); err != nil {
+		t.Fatal(err)
+	}
+	camera := hashira.NewCamera2D()
+	camera.Translate(400, 400)
+	r.Render(&Scene{World: w, Camera: camera, Screen: screen})
+
+	// 4 visible rows of a single chunk
+	if got, want := r.Stats(), (Stats{ChunksDrawn: 1, TilesDrawn: 4 * hashira.ChunkSize}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	// tile (100, 99) is the first one right above the center
+	if got, want := r.Image().RGBAAt(9, 6), (color.RGBA{0, 255, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := r.Image().RGBAAt(10, 10), (color.RGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
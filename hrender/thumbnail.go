@@ -0,0 +1,86 @@
+package hrender
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/qbart/hashira/ds"
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+)
+
+// Region is a rectangle in tile coordinates, Y grows down like rows in layer data.
+// Zero region means the whole map.
+type Region struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+func (r Region) IsZero() bool {
+	return r == Region{}
+}
+
+// RenderMap renders region of a single map using tilesets loaded into world resources.
+func RenderMap(w *hashira.World, mapName string, region Region, zoom float32, background hgl.Color) (*image.RGBA, error) {
+	m := w.Maps.Get(mapName)
+	if m == nil {
+		return nil, fmt.Errorf("map not found: %v", mapName)
+	}
+	if zoom <= 0 {
+		return nil, fmt.Errorf("invalid zoom: %v", zoom)
+	}
+	if region.IsZero() {
+		region = Region{Width: m.Width, Height: m.Height}
+	}
+	if region.X < 0 || region.Y < 0 || region.Width <= 0 || region.Height <= 0 ||
+		region.X+region.Width > m.Width || region.Y+region.Height > m.Height {
+		return nil, fmt.Errorf("region %v out of map bounds %dx%d", region, m.Width, m.Height)
+	}
+
+	// tiles outside of their tilesets are not drawn
+	w.Sync()
+	if w.SkipUVs {
+		w.BuildUVs(mapName)
+	}
+
+	// scene with requested map only, other maps could overlap it
+	maps := ds.NewHashMap[string, *hashira.Map]()
+	maps.Set(mapName, m)
+	world := &hashira.World{
+		Resources: w.Resources,
+		Maps:      maps,
+	}
+
+	tw := float32(m.TileWidth)
+	th := float32(m.TileHeight)
+	screen := &hgl.Screen{
+		Width:            int(math.Round(float64(float32(region.Width) * tw * zoom))),
+		Height:           int(math.Round(float64(float32(region.Height) * th * zoom))),
+		DevicePixelRatio: 1,
+	}
+	camera := hashira.NewCamera2D()
+	camera.SetZoom(zoom)
+	// mesh rows grow up from the bottom of the map
+	camera.Translate(
+		(float32(region.X)+float32(region.Width)/2)*tw,
+		(float32(m.Height-region.Y)-float32(region.Height)/2)*th,
+	)
+
+	r := NewSoftwareRenderer()
+	if err := r.Init(screen); err != nil {
+		return nil, err
+	}
+	w.Resources.Images.ForEach(func(name string, img *hgl.Image) {
+		r.LoadTexture(name, img, w.Resources.GetTextureOptions(name))
+	})
+	r.Render(&Scene{
+		World:      world,
+		Camera:     camera,
+		Screen:     screen,
+		Background: background,
+	})
+	return r.Image(), nil
+}
@@ -0,0 +1,86 @@
+package hrender
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+)
+
+func newThumbnailTestWorld(t *testing.T) *hashira.World {
+	t.Helper()
+	w := hashira.New()
+	if _, err := w.Resources.LoadTileset(hashira.DefaultTileset, newTestTileset(t), hashira.TilesetLayout{}); err != nil {
+		t.Fatal(err)
+	}
+	w.AddMap("main", 3, 2, 4, 4)
+	w.AddLayer("main", "ground", 0, "")
+	w.AddLayerData("main", "ground", [][]int{
+		{0, 1, 2},
+		{3, 4, 7},
+	})
+	// overlapping map which must not be drawn
+	w.AddMap("other", 3, 2, 4, 4)
+	w.AddLayer("other", "ground", 1, "")
+	w.AddLayerData("other", "ground", [][]int{
+		{4, 4, 4},
+		{4, 4, 4},
+	})
+	return w
+}
+
+func TestRenderMapWhole(t *testing.T) {
+	w := newThumbnailTestWorld(t)
+	img, err := RenderMap(w, "main", Region{}, 1, hgl.Color{0, 0, 0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got.X != 12 || got.Y != 8 {
+		t.Fatalf("got size %v, want 12x8", got)
+	}
+	if got, want := img.RGBAAt(1, 1), (color.RGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := img.RGBAAt(10, 6), (color.RGBA{255, 0, 255, 255}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRenderMapRegionZoom(t *testing.T) {
+	w := newThumbnailTestWorld(t)
+	img, err := RenderMap(w, "main", Region{X: 1, Y: 0, Width: 1, Height: 1}, 2, hgl.Color{0, 0, 0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got.X != 8 || got.Y != 8 {
+		t.Fatalf("got size %v, want 8x8", got)
+	}
+	// darker top left texel covers 2x2 pixels
+	dark := color.RGBA{0, 127, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := green
+			if x < 2 && y < 2 {
+				want = dark
+			}
+			if got := img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestRenderMapErrors(t *testing.T) {
+	w := newThumbnailTestWorld(t)
+	if _, err := RenderMap(w, "missing", Region{}, 1, hgl.Color{}); err == nil {
+		t.Error("expected error for missing map")
+	}
+	if _, err := RenderMap(w, "main", Region{}, 0, hgl.Color{}); err == nil {
+		t.Error("expected error for invalid zoom")
+	}
+	if _, err := RenderMap(w, "main", Region{X: 2, Width: 2, Height: 1}, 1, hgl.Color{}); err == nil {
+		t.Error("expected error for region out of bounds")
+	}
+}
@@ -0,0 +1,341 @@
+//go:build js && wasm
+
+package hrender
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/qbart/hashira/ds"
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hjs"
+	"github.com/qbart/hashira/hmath"
+)
+
+type WebGLRenderer struct {
+	GL   *hgl.WebGL
+	GLX  *hgl.WebGLExtended
+	Mode Mode
+
+	program       hgl.Program
+	locModel      hgl.Location
+	locView       hgl.Location
+	locProjection hgl.Location
+	locTileset    hgl.Location
+	locPosition   hgl.AttribLocation
+	locUV         hgl.AttribLocation
+	vao           hgl.VertexArrayObject
+	fbo           *hgl.FBO
+	matModel      hmath.Matrix4
+	textures      *ds.HashMap[string, hgl.Texture]
+	chunks        map[*hashira.Chunk]*chunkBuffers
+	// incremented every frame, used to find chunks that are gone
+	frame int
+	stats Stats
+	// set in ModeTileTexture
+	tiles *tileTextureProgram
+}
+
+// chunkBuffers are GPU buffers of a single chunk.
+type chunkBuffers struct {
+	vertices hgl.Buffer
+	// UVs and indices in submesh order
+	uvs     []hgl.Buffer
+	indices []hgl.Buffer
+	frame   int
+}
+
+// NewWebGLRenderer creates renderer drawing with given mode, ModeMesh is used when mode is empty.
+func NewWebGLRenderer(canvas hjs.Canvas, mode Mode) (*WebGLRenderer, error) {
+	if mode == "" {
+		mode = ModeMesh
+	}
+	if mode != ModeMesh && mode != ModeTileTexture {
+		return nil, fmt.Errorf("unknown render mode: %v", mode)
+	}
+	gl, err := hgl.NewWebGL(canvas)
+	if err != nil {
+		return nil, err
+	}
+	return &WebGLRenderer{
+		GL:       gl,
+		GLX:      gl.Extended(),
+		Mode:     mode,
+		textures: ds.NewHashMap[string, hgl.Texture](),
+		chunks:   make(map[*hashira.Chunk]*chunkBuffers),
+	}, nil
+}
+
+func (r *WebGLRenderer) Init(screen *hgl.Screen) error {
+	var err error
+	if r.Mode == ModeTileTexture {
+		r.tiles, err = newTileTextureProgram(r.GLX)
+	} else {
+		err = r.initMesh()
+	}
+	if err != nil {
+		return err
+	}
+
+	// fbo
+	fbo, err := r.GLX.CreateFBORenderTarget(screen.Width, screen.Height)
+	if err != nil {
+		return err
+	}
+	r.fbo = fbo
+
+	return nil
+}
+
+func (r *WebGLRenderer) initMesh() error {
+	gl := r.GL
+	glx := r.GLX
+
+	// shader tileset
+	program, err := glx.CreateDefaultProgram(hgl.VertexShaderSource, hgl.FragmentShaderSource)
+	if err != nil {
+		return err
+	}
+	r.program = program
+	gl.UseProgram(program)
+	r.matModel = hmath.IdentityMatrix()
+	r.locModel = gl.GetUniformLocation(program, "model")
+	r.locView = gl.GetUniformLocation(program, "view")
+	r.locProjection = gl.GetUniformLocation(program, "projection")
+	r.locTileset = gl.GetUniformLocation(program, "tileset")
+	// VAO tileset, buffers are bound per chunk
+	r.vao = gl.CreateVertexArray()
+	r.locPosition = gl.GetAttribLocation(program, "position")
+	r.locUV = gl.GetAttribLocation(program, "uv")
+
+	gl.BindVertexArray(r.vao)
+	gl.EnableVertexAttribArray(r.locPosition)
+	gl.EnableVertexAttribArray(r.locUV)
+	return nil
+}
+
+// MaxMapSize returns the largest map width or height the renderer can draw, 0 means no limit.
+// Tile textures of ModeTileTexture are limited by MAX_TEXTURE_SIZE.
+func (r *WebGLRenderer) MaxMapSize() int {
+	if r.tiles == nil {
+		return 0
+	}
+	return r.tiles.maxSize
+}
+
+func (r *WebGLRenderer) Resize(screen *hgl.Screen) {
+	r.fbo.Resize(r.GLX, *screen)
+}
+
+func (r *WebGLRenderer) LoadTexture(name string, img *hgl.Image, options hgl.TextureOptions) {
+	if r.textures.Has(name) {
+		r.GL.DeleteTexture(r.textures.Get(name))
+	}
+	r.textures.Set(name, r.GLX.CreateTextureRGBA(img, options))
+}
+
+func (r *WebGLRenderer) SetTextureOptions(name string, options hgl.TextureOptions) {
+	if r.textures.Has(name) {
+		r.GLX.SetTextureOptions(r.textures.Get(name), options)
+	}
+}
+
+func (r *WebGLRenderer) Render(scene *Scene) {
+	gl := r.GL
+	glx := r.GLX
+	screen := scene.Screen
+
+	// first pass - render to framebuffer
+	gl.BindFramebuffer(gl.Framebuffer, r.fbo.Framebuffer)
+
+	gl.Enable(gl.DepthTest)
+	glx.EnableTransparency()
+
+	gl.Viewport(0, 0, screen.Width, screen.Height)
+	glx.ClearColor(scene.Background)
+	gl.Clear(gl.ColorBufferBit | gl.DepthBufferBit)
+
+	r.frame++
+	r.stats = Stats{}
+	if r.Mode == ModeTileTexture {
+		r.stats = r.tiles.render(glx, scene, r.textures)
+	} else {
+		r.renderMesh(scene)
+	}
+	r.letterbox(scene)
+
+	gl.BindTexture(gl.Texture2D, gl.TextureNone)
+	gl.BindVertexArray(gl.VertexArrayObjectNone)
+	gl.BindFramebuffer(gl.Framebuffer, gl.FramebufferNone)
+
+	// second pass - render framebuffer to canvas
+	r.fbo.Draw(glx)
+}
+
+func (r *WebGLRenderer) renderMesh(scene *Scene) {
+	gl := r.GL
+	glx := r.GLX
+	camera := scene.Camera
+	screen := scene.Screen
+
+	camProjection := camera.Projection(screen)
+	gl.UseProgram(r.program)
+	gl.UniformMatrix4(r.locModel, r.matModel)
+	gl.UniformMatrix4(r.locView, camera.ViewMatrix)
+	gl.UniformMatrix4(r.locProjection, camProjection)
+	gl.Uniform1Int(r.locTileset, 1)
+
+	gl.ActiveTexture(gl.Texture1)
+	gl.BindVertexArray(r.vao)
+
+	rect := camera.VisibleRect(screen)
+	// tilesets packed into one atlas are drawn without rebinding
+	bound := ""
+	scene.World.Maps.ForEach(func(name string, m *hashira.Map) {
+		// chunks outside of the view are kept in sync too, only changes are uploaded
+		for _, c := range m.Chunks {
+			r.syncChunk(c)
+		}
+
+		visible := m.VisibleChunks(rect)
+		r.stats.ChunksDrawn += len(visible)
+		for _, v := range visible {
+			buffers := r.chunks[v.Chunk]
+			from, to := v.Quads()
+
+			gl.BindBuffer(gl.ArrayBuffer, buffers.vertices)
+			gl.VertexAttribPointer(r.locPosition, 3, gl.Float, false, 0, 0)
+			for i, subMesh := range v.Mesh.SubMeshes {
+				texture := scene.World.Resources.TextureName(m.SubMeshLayer(i).TilesetName())
+				if !r.textures.Has(texture) {
+					continue
+				}
+				if texture != bound {
+					glx.BindTexture2D(r.textures.Get(texture))
+					bound = texture
+				}
+				gl.UniformMatrix4(r.locModel, subMesh.Model)
+				gl.BindBuffer(gl.ArrayBuffer, buffers.uvs[i])
+				gl.VertexAttribPointer(r.locUV, 2, gl.Float, false, 0, 0)
+				gl.BindBuffer(gl.ElementArrayBuffer, buffers.indices[i])
+				// offset in bytes of uint32 indices
+				glx.DrawIndexedTriangles((to-from)*hgl.IndicesPerQuad, from*hgl.IndicesPerQuad*4)
+				r.stats.TilesDrawn += to - from
+			}
+		}
+	})
+	r.deleteStaleChunks()
+}
+
+// letterbox clears the framebuffer outside of camera viewport.
+func (r *WebGLRenderer) letterbox(scene *Scene) {
+	gl := r.GL
+	screen := scene.Screen
+	x, y, w, h := scene.Camera.Viewport(screen)
+	if w == screen.Width && h == screen.Height {
+		return
+	}
+
+	// scissor origin is at bottom left
+	bars := [][4]int{
+		{0, 0, x, screen.Height},
+		{x + w, 0, screen.Width - x - w, screen.Height},
+		{x, 0, w, screen.Height - y - h},
+		{x, screen.Height - y, w, y},
+	}
+	gl.Enable(gl.ScissorTest)
+	r.GLX.ClearColor(letterboxColor)
+	for _, bar := range bars {
+		gl.Scissor(bar[0], bar[1], bar[2], bar[3])
+		gl.Clear(gl.ColorBufferBit)
+	}
+	gl.Disable(gl.ScissorTest)
+}
+
+// syncChunk creates buffers of new chunks and layers and uploads UVs changed since last frame.
+// Vertices never change so they are uploaded only once.
+func (r *WebGLRenderer) syncChunk(c *hashira.Chunk) *chunkBuffers {
+	gl := r.GL
+	glx := r.GLX
+
+	buffers, ok := r.chunks[c]
+	if !ok {
+		buffers = &chunkBuffers{vertices: gl.CreateBuffer()}
+		gl.BindBuffer(gl.ArrayBuffer, buffers.vertices)
+		glx.BufferDataF(gl.ArrayBuffer, c.Mesh.Vertices.Data(), gl.StaticDraw)
+		r.chunks[c] = buffers
+	}
+	buffers.frame = r.frame
+
+	for i, subMesh := range c.Mesh.SubMeshes {
+		if i == len(buffers.uvs) {
+			buffers.uvs = append(buffers.uvs, gl.CreateBuffer())
+			gl.BindBuffer(gl.ArrayBuffer, buffers.uvs[i])
+			glx.BufferDataF(gl.ArrayBuffer, subMesh.UVs.Data(), gl.DynamicDraw)
+			buffers.indices = append(buffers.indices, gl.CreateBuffer())
+			gl.BindBuffer(gl.ElementArrayBuffer, buffers.indices[i])
+			glx.BufferDataU(gl.ElementArrayBuffer, subMesh.Indices.Data(), gl.DynamicDraw)
+			continue
+		}
+		if !subMesh.UVs.Data().Dirty().Empty() {
+			gl.BindBuffer(gl.ArrayBuffer, buffers.uvs[i])
+			glx.BufferDirtyDataF(gl.ArrayBuffer, subMesh.UVs.Data())
+		}
+		// empty tiles hide their quads
+		if !subMesh.Indices.Data().Dirty().Empty() {
+			gl.BindBuffer(gl.ElementArrayBuffer, buffers.indices[i])
+			glx.BufferDirtyDataU(gl.ElementArrayBuffer, subMesh.Indices.Data())
+		}
+	}
+
+	return buffers
+}
+
+// deleteStaleChunks releases buffers of chunks that were not rendered in the current frame
+// (map was replaced or removed).
+func (r *WebGLRenderer) deleteStaleChunks() {
+	for c, buffers := range r.chunks {
+		if buffers.frame != r.frame {
+			r.deleteChunkBuffers(buffers)
+			delete(r.chunks, c)
+		}
+	}
+}
+
+func (r *WebGLRenderer) deleteChunkBuffers(buffers *chunkBuffers) {
+	r.GL.DeleteBuffer(buffers.vertices)
+	for _, uv := range buffers.uvs {
+		r.GL.DeleteBuffer(uv)
+	}
+	for _, indices := range buffers.indices {
+		r.GL.DeleteBuffer(indices)
+	}
+}
+
+func (r *WebGLRenderer) Stats() Stats {
+	return r.stats
+}
+
+func (r *WebGLRenderer) Screenshot() *image.RGBA {
+	return r.fbo.ReadImage(r.GLX)
+}
+
+func (r *WebGLRenderer) Shutdown() {
+	gl := r.GL
+	r.textures.ForEach(func(_ string, texture hgl.Texture) {
+		gl.DeleteTexture(texture)
+	})
+	r.textures.Clear()
+	for c, buffers := range r.chunks {
+		r.deleteChunkBuffers(buffers)
+		delete(r.chunks, c)
+	}
+	if r.tiles != nil {
+		r.tiles.delete(r.GLX)
+	} else {
+		gl.DeleteVertexArray(r.vao)
+		gl.DeleteProgram(r.program)
+	}
+	r.fbo.Delete(r.GLX)
+}
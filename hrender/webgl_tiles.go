@@ -0,0 +1,277 @@
+//go:build js && wasm
+
+package hrender
+
+import (
+	"github.com/qbart/hashira/ds"
+	"github.com/qbart/hashira/hashira"
+	"github.com/qbart/hashira/hgl"
+	"github.com/qbart/hashira/hmath"
+)
+
+// tileTextureProgram draws every layer as a single quad (ModeTileTexture),
+// tile ids are kept in integer textures updated from chunk dirty ranges.
+type tileTextureProgram struct {
+	program       hgl.Program
+	locModel      hgl.Location
+	locView       hgl.Location
+	locProjection hgl.Location
+	locTileset    hgl.Location
+	locTiles      hgl.Location
+	locMapSize    hgl.Location
+	locTileSize   hgl.Location
+	locMargin     hgl.Location
+	locSpacing    hgl.Location
+	locColumns    hgl.Location
+	locTileCount  hgl.Location
+	locRegions    hgl.Location
+	locUseRegions hgl.Location
+	vao           hgl.VertexArrayObject
+	vertexBuffer  hgl.Buffer
+	indexBuffer   hgl.Buffer
+	// visible part of the map being drawn
+	quad *hgl.VertexBuffer3f
+	maps map[*hashira.Map]*mapTiles
+	// atlas regions of packed tilesets, see Tileset.Regions
+	regions map[*hashira.Tileset]*tilesetRegions
+	// MAX_TEXTURE_SIZE, larger maps are not drawn
+	maxSize int
+}
+
+// tilesetRegions keep atlas position of every tile packed as x | y << 16.
+type tilesetRegions struct {
+	texture hgl.Texture
+}
+
+// regionsPerRow limits width of regions texture, see TileTextureFragmentShaderSource.
+const regionsPerRow = 1024
+
+// mapTiles are tile textures of a single map.
+type mapTiles struct {
+	// in submesh order
+	layers []hgl.Texture
+}
+
+func newTileTextureProgram(glx *hgl.WebGLExtended) (*tileTextureProgram, error) {
+	program, err := glx.CreateDefaultProgram(hgl.TileTextureVertexShaderSource, hgl.TileTextureFragmentShaderSource)
+	if err != nil {
+		return nil, err
+	}
+	t := &tileTextureProgram{
+		program:       program,
+		locModel:      glx.GetUniformLocation(program, "model"),
+		locView:       glx.GetUniformLocation(program, "view"),
+		locProjection: glx.GetUniformLocation(program, "projection"),
+		locTileset:    glx.GetUniformLocation(program, "tileset"),
+		locTiles:      glx.GetUniformLocation(program, "tiles"),
+		locMapSize:    glx.GetUniformLocation(program, "mapSize"),
+		locTileSize:   glx.GetUniformLocation(program, "tileSize"),
+		locMargin:     glx.GetUniformLocation(program, "tilesetMargin"),
+		locSpacing:    glx.GetUniformLocation(program, "tilesetSpacing"),
+		locColumns:    glx.GetUniformLocation(program, "tilesetColumns"),
+		locTileCount:  glx.GetUniformLocation(program, "tileCount"),
+		locRegions:    glx.GetUniformLocation(program, "regions"),
+		locUseRegions: glx.GetUniformLocation(program, "useRegions"),
+		vao:           glx.CreateVertexArray(),
+		vertexBuffer:  glx.CreateBuffer(),
+		indexBuffer:   glx.CreateBuffer(),
+		quad:          hgl.NewVertexBuffer3f(hgl.VerticesPerQuad),
+		maps:          make(map[*hashira.Map]*mapTiles),
+		regions:       make(map[*hashira.Tileset]*tilesetRegions),
+		maxSize:       glx.GetInteger(glx.MaxTextureSize),
+	}
+
+	glx.BindVertexArray(t.vao)
+	glx.AssignAttribToBuffer(program, "position", t.vertexBuffer, glx.Float, 3)
+	glx.BindBuffer(glx.ElementArrayBuffer, t.indexBuffer)
+	glx.BufferDataU(glx.ElementArrayBuffer, hgl.NewQuadIndexBuffer(1).Data(), glx.StaticDraw)
+	glx.BindVertexArray(glx.VertexArrayObjectNone)
+
+	return t, nil
+}
+
+func (t *tileTextureProgram) render(glx *hgl.WebGLExtended, scene *Scene, textures *ds.HashMap[string, hgl.Texture]) Stats {
+	camera := scene.Camera
+	stats := Stats{}
+
+	glx.UseProgram(t.program)
+	glx.UniformMatrix4(t.locView, camera.ViewMatrix)
+	glx.UniformMatrix4(t.locProjection, camera.Projection(scene.Screen))
+	glx.Uniform1Int(t.locTileset, 1)
+	glx.Uniform1Int(t.locTiles, 2)
+	glx.Uniform1Int(t.locRegions, 3)
+	glx.BindVertexArray(t.vao)
+	t.deleteRemoved(glx, scene.World)
+
+	rect := camera.VisibleRect(scene.Screen)
+	scene.World.Maps.ForEach(func(_ string, m *hashira.Map) {
+		// reported by WebGLRenderer.MaxMapSize, GPU would reject the texture
+		if m.Width > t.maxSize || m.Height > t.maxSize {
+			return
+		}
+		tiles := t.sync(glx, m)
+
+		x0, y0, x1, y1, ok := m.VisibleTiles(rect)
+		if !ok {
+			return
+		}
+		tw := float32(m.TileWidth)
+		th := float32(m.TileHeight)
+		t.quad.SetQuad(0, float32(x0)*tw, float32(y0)*th, float32(x1)*tw, float32(y1)*th, 0)
+		glx.BindBuffer(glx.ArrayBuffer, t.vertexBuffer)
+		glx.BufferDataF(glx.ArrayBuffer, t.quad.Data(), glx.DynamicDraw)
+
+		glx.Uniform2f(t.locMapSize, float32(m.Width), float32(m.Height))
+		glx.Uniform2f(t.locTileSize, tw, th)
+		for i, layerTiles := range tiles.layers {
+			layer := m.SubMeshLayer(i)
+			tileset := scene.World.Resources.GetTileset(layer.TilesetName())
+			if tileset == nil || !textures.Has(tileset.TextureName()) {
+				continue
+			}
+			columns, count := tileset.Grid(m.TileWidth, m.TileHeight)
+			if tileset.Regions != nil {
+				columns, count = 1, len(tileset.Regions)
+				glx.ActiveTexture(glx.Texture3)
+				glx.BindTexture2D(t.syncRegions(glx, tileset))
+				glx.Uniform1Int(t.locUseRegions, 1)
+			} else {
+				glx.Uniform1Int(t.locUseRegions, 0)
+			}
+			if columns == 0 {
+				continue
+			}
+			glx.ActiveTexture(glx.Texture1)
+			glx.BindTexture2D(textures.Get(tileset.TextureName()))
+			glx.ActiveTexture(glx.Texture2)
+			glx.BindTexture2D(layerTiles)
+			glx.Uniform1Int(t.locMargin, tileset.Margin)
+			glx.Uniform1Int(t.locSpacing, tileset.Spacing)
+			glx.Uniform1Int(t.locColumns, columns)
+			glx.Uniform1Int(t.locTileCount, count)
+			glx.UniformMatrix4(t.locModel, hmath.TranslationMatrix(hmath.Vertex{0, 0, layer.Z}))
+			glx.DrawIndexedTriangles(hgl.IndicesPerQuad, 0)
+			stats.TilesDrawn += (x1 - x0) * (y1 - y0)
+		}
+	})
+
+	glx.ActiveTexture(glx.Texture3)
+	glx.BindTexture2D(glx.TextureNone)
+	glx.ActiveTexture(glx.Texture2)
+	glx.BindTexture2D(glx.TextureNone)
+	glx.ActiveTexture(glx.Texture1)
+
+	return stats
+}
+
+// sync creates textures of new layers and uploads tiles changed since last frame,
+// changes in a single row (e.g. SetTile) upload only the changed texels.
+func (t *tileTextureProgram) sync(glx *hgl.WebGLExtended, m *hashira.Map) *mapTiles {
+	tiles, ok := t.maps[m]
+	if !ok {
+		tiles = &mapTiles{}
+		t.maps[m] = tiles
+	}
+
+	for i := range m.SubMeshLayerNames {
+		layer := m.SubMeshLayer(i)
+		if i == len(tiles.layers) {
+			tiles.layers = append(tiles.layers, glx.CreateTileIndexTexture(m.Width, m.Height, visibleTiles(layer, 0, 0, m.Width, m.Height)))
+			for _, c := range m.Chunks {
+				c.ClearDirty(i)
+			}
+			continue
+		}
+
+		for _, c := range m.Chunks {
+			// texture rows are top down as in layer data
+			x0, y0, x1, y1, ok := m.DirtyRect(c, i)
+			if !ok {
+				continue
+			}
+			glx.BindTexture2D(tiles.layers[i])
+			glx.TexSubImage2DR32I(x0, y0, x1-x0, y1-y0, visibleTiles(layer, x0, y0, x1, y1))
+			c.ClearDirty(i)
+		}
+	}
+	glx.BindTexture2D(glx.TextureNone)
+
+	return tiles
+}
+
+// syncRegions creates regions texture of the tileset, tilesets are replaced when packed again.
+func (t *tileTextureProgram) syncRegions(glx *hgl.WebGLExtended, tileset *hashira.Tileset) hgl.Texture {
+	regions, ok := t.regions[tileset]
+	if !ok {
+		width := len(tileset.Regions)
+		if width > regionsPerRow {
+			width = regionsPerRow
+		}
+		height := (len(tileset.Regions) + regionsPerRow - 1) / regionsPerRow
+		data := make([]int32, width*height)
+		for i, r := range tileset.Regions {
+			data[i] = int32(r.X | r.Y<<16)
+		}
+		regions = &tilesetRegions{texture: glx.CreateTileIndexTexture(width, height, data)}
+		t.regions[tileset] = regions
+	}
+	return regions.texture
+}
+
+// visibleTiles returns tiles (current animation frames) of layer data rectangle in row major order.
+func visibleTiles(layer *hashira.Layer, x0, y0, x1, y1 int) []int32 {
+	data := make([]int32, 0, (x1-x0)*(y1-y0))
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			data = append(data, int32(layer.VisibleTile(x, y)))
+		}
+	}
+	return data
+}
+
+// deleteRemoved releases textures of maps and tilesets that were removed from the world or replaced,
+// textures of culled maps and tilesets are kept.
+func (t *tileTextureProgram) deleteRemoved(glx *hgl.WebGLExtended, world *hashira.World) {
+	maps := make(map[*hashira.Map]bool, world.Maps.Len())
+	world.Maps.ForEach(func(_ string, m *hashira.Map) {
+		maps[m] = true
+	})
+	for m, tiles := range t.maps {
+		if !maps[m] {
+			t.deleteMapTiles(glx, tiles)
+			delete(t.maps, m)
+		}
+	}
+
+	tilesets := make(map[*hashira.Tileset]bool, world.Resources.Tilesets.Len())
+	world.Resources.Tilesets.ForEach(func(_ string, tileset *hashira.Tileset) {
+		tilesets[tileset] = true
+	})
+	for tileset, regions := range t.regions {
+		if !tilesets[tileset] {
+			glx.DeleteTexture(regions.texture)
+			delete(t.regions, tileset)
+		}
+	}
+}
+
+func (t *tileTextureProgram) deleteMapTiles(glx *hgl.WebGLExtended, tiles *mapTiles) {
+	for _, texture := range tiles.layers {
+		glx.DeleteTexture(texture)
+	}
+}
+
+func (t *tileTextureProgram) delete(glx *hgl.WebGLExtended) {
+	for m, tiles := range t.maps {
+		t.deleteMapTiles(glx, tiles)
+		delete(t.maps, m)
+	}
+	for tileset, regions := range t.regions {
+		glx.DeleteTexture(regions.texture)
+		delete(t.regions, tileset)
+	}
+	glx.DeleteBuffer(t.vertexBuffer)
+	glx.DeleteBuffer(t.indexBuffer)
+	glx.DeleteVertexArray(t.vao)
+	glx.DeleteProgram(t.program)
+}
@@ -1,55 +1,49 @@
+//go:build js && wasm
+
 package hsystem
 
 import (
+	"bytes"
 	"fmt"
+	"image/png"
+	"time"
 
 	"github.com/SoftKiwiGames/risky/risky"
 	"github.com/qbart/hashira/hashira"
 	"github.com/qbart/hashira/hgl"
 	"github.com/qbart/hashira/hjs"
-	"github.com/qbart/hashira/hmath"
+	"github.com/qbart/hashira/hrender"
 	"github.com/qbart/hashira/hsystem/hevents"
+	"github.com/qbart/hashira/htiled"
 )
 
 type App interface {
 	Init() error
 	Tick(dt float32)
+	// Shutdown releases all resources, app can't be used afterwards.
+	Shutdown()
 }
 
 type DefaultApp struct {
 	Canvas   hjs.Canvas
 	Commands *Commands
+	Outbound *Outbound
+	// Renderer draws the world, WebGL renderer is used when nil.
+	Renderer hrender.Renderer
+	// RenderMode of the default WebGL renderer.
+	RenderMode hrender.Mode
 
-	GL  *hgl.WebGL
-	GLX *hgl.WebGLExtended
-
-	screen        *hgl.Screen
-	program       hgl.Program
-	locModel      hgl.Location
-	locView       hgl.Location
-	locProjection hgl.Location
-	locTileset    hgl.Location
-	vao           hgl.VertexArrayObject
-	vertexBuffer  hgl.Buffer
-
-	uvBuffer hgl.Buffer
-	fbo      *hgl.FBO
-
+	screen          *hgl.Screen
 	world           *hashira.World
 	camera          *hashira.Camera2D
-	matModel        hmath.Matrix4
+	hovered         *hashira.TilePick
+	stats           frameStats
 	backgroundColor hgl.Color
+	// outbound listeners can stop the app in the middle of a frame
+	stopped bool
 }
 
 func (app *DefaultApp) Init() error {
-	gl, err := hgl.NewWebGL(app.Canvas)
-	if err != nil {
-		return err
-	}
-	glx := gl.Extended()
-	app.GL = gl
-	app.GLX = glx
-
 	app.world = hashira.New()
 	app.backgroundColor = hgl.Color{1, 1, 1, 1}
 
@@ -58,117 +52,192 @@ func (app *DefaultApp) Init() error {
 		Height:           app.Canvas.GetClientHeightDPR(),
 		DevicePixelRatio: app.Canvas.DevicePixelRatio(),
 	}
-	app.camera = &hashira.Camera2D{Zoom: 1}
+	app.camera = hashira.NewCamera2D()
 
-	// shader tileset
-	program, err := glx.CreateDefaultProgram(hgl.VertexShaderSource, hgl.FragmentShaderSource)
-	if err != nil {
-		return err
+	if app.Renderer == nil {
+		renderer, err := hrender.NewWebGLRenderer(app.Canvas, app.RenderMode)
+		if err != nil {
+			return err
+		}
+		app.Renderer = renderer
 	}
-	app.program = program
-	gl.UseProgram(program)
-	app.matModel = hmath.IdentityMatrix()
-	app.locModel = gl.GetUniformLocation(program, "model")
-	app.locView = gl.GetUniformLocation(program, "view")
-	app.locProjection = gl.GetUniformLocation(program, "projection")
-	app.locTileset = gl.GetUniformLocation(program, "tileset")
-	// VAO tileset
-	app.vao = gl.CreateVertexArray()
-	app.vertexBuffer = gl.CreateBuffer()
-	app.uvBuffer = gl.CreateBuffer()
-
-	gl.BindVertexArray(app.vao)
-	glx.AssignAttribToBuffer(program, "position", app.vertexBuffer, gl.Float, 3)
-	glx.AssignAttribToBuffer(program, "uv", app.uvBuffer, gl.Float, 2)
-
-	// fbo
-	fbo, err := glx.CreateFBORenderTarget(app.screen.Width, app.screen.Height)
-	if err != nil {
-		return err
+	// tile textures are built from layer data, UVs are not needed
+	if r, ok := app.Renderer.(*hrender.WebGLRenderer); ok && r.Mode == hrender.ModeTileTexture {
+		app.world.SkipUVs = true
 	}
-	app.fbo = fbo
 
-	return nil
+	return app.Renderer.Init(app.screen)
 }
 
 func (app *DefaultApp) Tick(dt float32) {
-	gl := app.GL
-	glx := app.GLX
-
 	app.world.Sync()
-
-	// for _, animatedTile := range o.animatedTiles {
-	// 	if animatedTile.Update(dt) {
-	// 		SetTileAt(o.world.Maps.Get("main"), mesh.SubMeshes[animatedTile.Layer], &o.tileset, animatedTile.X, animatedTile.Y, animatedTile.Tile())
-	// 	}
-	// }
-
-	// first pass - render to framebuffer
-	gl.BindFramebuffer(gl.Framebuffer, app.fbo.Framebuffer)
-
-	gl.Enable(gl.DepthTest)
-	glx.EnableTransparency()
-
-	camProjection := app.camera.Projection(app.screen)
-	gl.Viewport(0, 0, app.screen.Width, app.screen.Height)
-	glx.ClearColor(app.backgroundColor)
-	gl.Clear(gl.ColorBufferBit | gl.DepthBufferBit)
-
-	gl.UseProgram(app.program)
-	gl.UniformMatrix4(app.locModel, app.matModel)
-	gl.UniformMatrix4(app.locView, app.camera.ViewMatrix)
-	gl.UniformMatrix4(app.locProjection, camProjection)
-	gl.Uniform1Int(app.locTileset, 1)
-
-	if app.world.Resources.HasTileset() {
-		gl.ActiveTexture(gl.Texture1)
-		glx.BindTexture2D(app.world.Resources.Texture)
+	if err := app.world.Update(dt); err != nil {
+		app.emitError(&Event{}, fmt.Errorf("error updating animations: %v", err))
+	}
+	app.camera.Update(dt, app.screen)
+	if app.stopped {
+		return
 	}
 
-	gl.BindVertexArray(app.vao)
+	app.Renderer.Render(&hrender.Scene{
+		World:      app.world,
+		Camera:     app.camera,
+		Screen:     app.screen,
+		Background: app.backgroundColor,
+	})
 
-	app.world.Maps.ForEach(func(name string, m *hashira.Map) {
-		gl.BindBuffer(gl.ArrayBuffer, app.vertexBuffer)
-		glx.BufferDataF(gl.ArrayBuffer, m.Mesh.Vertices.Data(), gl.DynamicDraw)
+	if app.stats.Update(dt) {
+		renderStats := app.Renderer.Stats()
+		app.Outbound.Emit("FrameStats", hevents.FrameStats{
+			FPS:          app.stats.FPS(),
+			FrameTime:    app.stats.FrameTime(),
+			QueuedEvents: app.Commands.Len(),
+			ChunksDrawn:  renderStats.ChunksDrawn,
+			TilesDrawn:   renderStats.TilesDrawn,
+		})
+		app.stats.Reset()
+	}
+	if app.stopped {
+		return
+	}
 
-		gl.BindBuffer(gl.ArrayBuffer, app.uvBuffer)
-		for _, subMesh := range m.Mesh.SubMeshes {
-			gl.UniformMatrix4(app.locModel, subMesh.Model)
-			glx.BufferDataF(gl.ArrayBuffer, subMesh.UVs.Data(), gl.DynamicDraw)
-			glx.DrawTriangles(0, m.Mesh.Vertices.Len())
-		}
-	})
+	app.handleEvents()
+}
 
-	gl.BindTexture(gl.Texture2D, gl.TextureNone)
-	gl.BindVertexArray(gl.VertexArrayObjectNone)
-	gl.BindFramebuffer(gl.Framebuffer, gl.FramebufferNone)
+func (app *DefaultApp) Shutdown() {
+	app.stopped = true
+	app.Renderer.Shutdown()
+}
 
-	// second pass - render framebuffer to canvas
-	app.fbo.Draw(glx)
+// events that can be sent with sendBinaryEvent of the instance handle
+var binaryEvents = map[string]bool{
+	"TilesetLoaded":  true,
+	"TiledMapLoaded": true,
+	"WorldLoaded":    true,
+	"LayerDataAdded": true,
+	"TileAssigned":   true,
+}
 
-	if app.Commands.HasEvents() {
-		event := app.Commands.PeekEvent()
-		app.handleEvent(event)
+// handleEvents processes queued events within the commands budget,
+// events queued while handling them (e.g. by outbound listeners) wait for the next frame.
+// Remaining events are dropped when a listener stops the app.
+func (app *DefaultApp) handleEvents() {
+	start := time.Now()
+	queued := app.Commands.Len()
+	for processed := 0; processed < queued && app.Commands.HasEvents() && !app.stopped; processed++ {
+		if app.Commands.Budget.Exceeded(processed, time.Since(start)) {
+			break
+		}
+		app.handleEvent(app.Commands.PeekEvent())
 	}
 }
 
 func (app *DefaultApp) handleEvent(event *Event) {
+	if event.Binary && !binaryEvents[event.Type] {
+		app.emitError(event, fmt.Errorf("binary payload is not supported for event: %v", event.Type))
+		return
+	}
+
 	switch event.Type {
 	case "TilesetLoaded":
-		data := risky.JSON[hevents.TilesetLoaded](event.Payload)
-		img, err := app.world.Resources.LoadTileset(data.Bytes)
+		data, err := decodeEvent[hevents.TilesetLoaded](event)
 		if err != nil {
-			fmt.Println("Error loading tileset: ", err)
+			app.emitError(event, err)
 			return
 		}
-		app.world.Resources.Texture = app.GLX.CreateDefaultTextureRGBA(img)
+		name := data.Name
+		if name == "" {
+			name = hashira.DefaultTileset
+		}
+		img, err := app.world.Resources.LoadTileset(name, data.Bytes, hashira.TilesetLayout{
+			Margin:    data.Margin,
+			Spacing:   data.Spacing,
+			Columns:   data.Columns,
+			TileCount: data.TileCount,
+		})
+		if err != nil {
+			app.emitError(event, fmt.Errorf("error loading tileset: %v", err))
+			return
+		}
+		app.Renderer.LoadTexture(name, img, app.world.Resources.GetTextureOptions(name))
+		app.world.Resync()
+		// tiles placed before the tileset was loaded are checked now
+		if err := app.world.Sync(); err != nil {
+			app.emitError(event, err)
+		}
+		app.Outbound.Emit("TilesetReady", hevents.TilesetReady{
+			Name:   name,
+			Width:  img.Width,
+			Height: img.Height,
+		})
+
+	case "TilesetsPacked":
+		data := risky.JSON[hevents.TilesetsPacked](event.Payload)
+		img, err := app.world.Resources.PackAtlas(data.Name, data.TileWidth, data.TileHeight, data.Extrude, data.Tilesets...)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		app.Renderer.LoadTexture(data.Name, img, app.world.Resources.GetTextureOptions(data.Name))
 		app.world.Resync()
 
+	case "TextureFilteringSet":
+		data := risky.JSON[hevents.TextureFilteringSet](event.Payload)
+		resources := app.world.Resources
+		err := resources.SetTextureOptions(data.Texture, hgl.TextureOptions{
+			Filter:  hgl.TextureFilter(data.Filter),
+			Mipmaps: data.Mipmaps,
+			Wrap:    hgl.TextureWrap(data.Wrap),
+		})
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		resources.Images.ForEach(func(name string, _ *hgl.Image) {
+			app.Renderer.SetTextureOptions(name, resources.GetTextureOptions(name))
+		})
+
+	case "WorldExported":
+		data := risky.JSON[hevents.WorldExported](event.Payload)
+		format := hashira.SaveFormat(data.Format)
+		if format == "" {
+			format = hashira.SaveFormatJSON
+		}
+		b, err := app.world.Save(format)
+		if err != nil {
+			app.emitError(event, fmt.Errorf("error exporting world: %v", err))
+			return
+		}
+		app.Outbound.EmitBytes("WorldSerialized", b)
+
+	case "WorldLoaded":
+		data, err := decodeEvent[hevents.WorldLoaded](event)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		if err := app.world.Load(data.Bytes); err != nil {
+			app.emitError(event, fmt.Errorf("error loading world: %v", err))
+			return
+		}
+		app.world.Maps.ForEach(func(name string, _ *hashira.Map) {
+			app.emitMapReady(event, name)
+		})
+
+	case "ScreenshotRequested":
+		// last rendered frame, events are handled after rendering
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, app.Renderer.Screenshot()); err != nil {
+			app.emitError(event, fmt.Errorf("error encoding screenshot: %v", err))
+			return
+		}
+		app.Outbound.EmitBytes("ScreenshotTaken", buf.Bytes())
+
 	case "ScreenResized":
 		data := risky.JSON[hevents.ScreenResized](event.Payload)
 		app.screen.Resize(data.Width, data.Height)
 		app.Canvas.Resize()
-		app.fbo.Resize(app.GLX, *app.screen)
+		app.Renderer.Resize(app.screen)
 
 	case "BackgroundColorSet":
 		data := risky.JSON[hevents.BackgroundColorSet](event.Payload)
@@ -176,19 +245,87 @@ func (app *DefaultApp) handleEvent(event *Event) {
 
 	case "MapAdded":
 		data := risky.JSON[hevents.MapAdded](event.Payload)
+		size := &hashira.MapData{
+			Name:       data.Name,
+			Width:      data.Width,
+			Height:     data.Height,
+			TileWidth:  data.TileWidth,
+			TileHeight: data.TileHeight,
+		}
+		if err := size.Validate(); err != nil {
+			app.emitError(event, err)
+			return
+		}
 		app.world.AddMap(data.Name, data.Width, data.Height, data.TileWidth, data.TileHeight)
+		app.emitMapReady(event, data.Name)
+
+	case "TiledMapLoaded":
+		data, err := decodeEvent[hevents.TiledMapLoaded](event)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		if err := htiled.Import(app.world, data.Name, data.Bytes); err != nil {
+			app.emitError(event, fmt.Errorf("error loading tiled map: %v", err))
+			return
+		}
+		app.emitMapReady(event, data.Name)
 
 	case "LayerAdded":
 		data := risky.JSON[hevents.LayerAdded](event.Payload)
-		app.world.AddLayer(data.Map, data.Name, data.Z)
+		if !app.world.Maps.Has(data.Map) {
+			app.emitError(event, fmt.Errorf("unknown map: %v", data.Map))
+			return
+		}
+		app.world.AddLayer(data.Map, data.Name, data.Z, data.Tileset)
 
 	case "LayerDataAdded":
-		data := risky.JSON[hevents.LayerDataAdded](event.Payload)
-		app.world.AddLayerData(data.Map, data.Layer, data.Data)
+		data, err := decodeEvent[hevents.LayerDataAdded](event)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		m, err := app.findLayer(data.Map, data.Layer)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		// binary width and height are checked as well, decoded rows have the payload size
+		if err := checkLayerData(m, data.Data); err != nil {
+			app.emitError(event, err)
+			return
+		}
+		if err := app.world.AddLayerData(data.Map, data.Layer, data.Data); err != nil {
+			app.emitError(event, err)
+		}
 
 	case "TileAssigned":
-		data := risky.JSON[hevents.TileAssigned](event.Payload)
-		app.world.SetTile(data.Map, data.Layer, data.X, data.Y, data.Tile)
+		data, err := decodeEvent[hevents.TileAssigned](event)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		m, err := app.findLayer(data.Map, data.Layer)
+		if err != nil {
+			app.emitError(event, err)
+			return
+		}
+		if data.X < 0 || data.Y < 0 || data.X >= m.Width || data.Y >= m.Height {
+			app.emitError(event, fmt.Errorf("tile (%d, %d) outside of map %q", data.X, data.Y, data.Map))
+			return
+		}
+		if err := app.world.SetTile(data.Map, data.Layer, data.X, data.Y, data.Tile); err != nil {
+			app.emitError(event, err)
+		}
+
+	case "AnimationDefined":
+		data := risky.JSON[hevents.AnimationDefined](event.Payload)
+		if err := app.world.DefineAnimation(data.Frames, data.Delay); err != nil {
+			app.emitError(event, err)
+		}
+
+	case "AnimationsCleared":
+		app.world.ClearAnimations()
 
 	case "CameraTranslated":
 		data := risky.JSON[hevents.CameraTranslated](event.Payload)
@@ -198,6 +335,10 @@ func (app *DefaultApp) handleEvent(event *Event) {
 		data := risky.JSON[hevents.CameraTranslatedBy](event.Payload)
 		app.camera.TranslateBy(data.X, data.Y)
 
+	case "CameraDragged":
+		data := risky.JSON[hevents.CameraDragged](event.Payload)
+		app.camera.Drag(data.X, data.Y)
+
 	case "CameraZoomed":
 		data := risky.JSON[hevents.CameraZoomed](event.Payload)
 		app.camera.SetZoom(data.Zoom)
@@ -206,15 +347,150 @@ func (app *DefaultApp) handleEvent(event *Event) {
 		data := risky.JSON[hevents.CameraZoomedBy](event.Payload)
 		app.camera.ZoomBy(data.Delta)
 
+	case "CameraMovedTo":
+		data := risky.JSON[hevents.CameraMovedTo](event.Payload)
+		if err := app.camera.MoveTo(data.X, data.Y, data.Zoom, data.Duration, data.Easing); err != nil {
+			app.emitError(event, err)
+		}
+
+	case "CameraFollowed":
+		data := risky.JSON[hevents.CameraFollowed](event.Payload)
+		if err := app.camera.Follow(data.X, data.Y, data.Speed); err != nil {
+			app.emitError(event, err)
+		}
+
+	case "CameraFollowStopped":
+		app.camera.StopFollow()
+
+	case "CameraDragReleased":
+		app.camera.ReleaseDrag()
+
+	case "CameraBoundsSet":
+		data := risky.JSON[hevents.CameraBoundsSet](event.Payload)
+		bounds := hashira.Rect{MinX: data.MinX, MinY: data.MinY, MaxX: data.MaxX, MaxY: data.MaxY}
+		if data.Map != "" {
+			if !app.world.Maps.Has(data.Map) {
+				app.emitError(event, fmt.Errorf("unknown map: %v", data.Map))
+				return
+			}
+			bounds = app.world.Maps.Get(data.Map).Rect()
+		}
+		if bounds.MinX >= bounds.MaxX || bounds.MinY >= bounds.MaxY {
+			app.emitError(event, fmt.Errorf("invalid camera bounds: %v", bounds))
+			return
+		}
+		app.camera.Bounds = &bounds
+
+	case "CameraBoundsCleared":
+		app.camera.Bounds = nil
+
+	case "CameraPixelPerfectSet":
+		data := risky.JSON[hevents.CameraPixelPerfectSet](event.Payload)
+		if err := app.camera.SetPixelPerfect(data.Enabled, data.ZoomStep); err != nil {
+			app.emitError(event, err)
+		}
+
 	case "CameraTranslatedToMapCenter":
 		data := risky.JSON[hevents.CameraTranslatedToMapCenter](event.Payload)
+		if !app.world.Maps.Has(data.Map) {
+			app.emitError(event, fmt.Errorf("unknown map: %v", data.Map))
+			return
+		}
 		m := app.world.Maps.Get(data.Map)
 		cx, cy := m.Center()
 		cx *= float32(m.TileWidth)
 		cy *= float32(m.TileHeight)
 		app.camera.Translate(cx, cy)
 
+	case "PointerMoved":
+		data := risky.JSON[hevents.PointerMoved](event.Payload)
+		pick, ok := app.pickTile(data.X, data.Y)
+		if !ok {
+			app.hovered = nil
+			return
+		}
+		if app.hovered != nil && *app.hovered == pick {
+			return
+		}
+		app.hovered = &pick
+		app.Outbound.Emit("TileHovered", hevents.TileHovered(pick))
+
+	case "PointerClicked":
+		data := risky.JSON[hevents.PointerClicked](event.Payload)
+		if pick, ok := app.pickTile(data.X, data.Y); ok {
+			app.Outbound.Emit("TileClicked", hevents.TileClicked(pick))
+		}
+
 	default:
-		fmt.Println("Unknown event: ", event.Type)
+		app.emitError(event, fmt.Errorf("unknown event: %v", event.Type))
+	}
+}
+
+// pickTile finds tile under pointer position given in CSS pixels.
+func (app *DefaultApp) pickTile(x, y float32) (hashira.TilePick, bool) {
+	dpr := app.screen.DevicePixelRatio
+	x *= dpr
+	y *= dpr
+	// letterbox hides tiles
+	vx, vy, vw, vh := app.camera.Viewport(app.screen)
+	if x < float32(vx) || y < float32(vy) || x >= float32(vx+vw) || y >= float32(vy+vh) {
+		return hashira.TilePick{}, false
+	}
+	wx, wy := app.camera.ScreenToWorld(app.screen, x, y)
+	return app.world.PickTile(wx, wy)
+}
+
+// emitError logs the error and reports it to JS.
+func (app *DefaultApp) emitError(event *Event, err error) {
+	fmt.Println(event.Type, err)
+	app.Outbound.Emit("ErrorOccurred", hevents.ErrorOccurred{
+		Event:   event.Type,
+		Message: err.Error(),
+	})
+}
+
+// findLayer returns map of the layer, unknown names are reported.
+func (app *DefaultApp) findLayer(mapName, layerName string) (*hashira.Map, error) {
+	if !app.world.Maps.Has(mapName) {
+		return nil, fmt.Errorf("unknown map: %v", mapName)
+	}
+	m := app.world.Maps.Get(mapName)
+	if !m.Layers.Has(layerName) {
+		return nil, fmt.Errorf("unknown layer %q of map %q", layerName, mapName)
+	}
+	return m, nil
+}
+
+// checkLayerData reports data that doesn't cover the whole map.
+func checkLayerData(m *hashira.Map, data [][]int) error {
+	if len(data) != m.Height {
+		return fmt.Errorf("expected %d rows, got %d", m.Height, len(data))
+	}
+	for y, row := range data {
+		if len(row) != m.Width {
+			return fmt.Errorf("expected %d tiles in row %d, got %d", m.Width, y, len(row))
+		}
+	}
+	return nil
+}
+
+// emitMapReady reports added map, maps too large for the renderer are reported as errors.
+func (app *DefaultApp) emitMapReady(event *Event, name string) {
+	m := app.world.Maps.Get(name)
+	if m == nil {
+		return
+	}
+	if r, ok := app.Renderer.(*hrender.WebGLRenderer); ok {
+		if size := r.MaxMapSize(); size > 0 && (m.Width > size || m.Height > size) {
+			app.emitError(event, fmt.Errorf("map %q of %dx%d tiles exceeds maximum size %d of %v render mode, it is not drawn", name, m.Width, m.Height, size, r.Mode))
+		}
 	}
+	app.Outbound.Emit("MapReady", hevents.MapReady{
+		Name:       name,
+		Width:      m.Width,
+		Height:     m.Height,
+		TileWidth:  m.TileWidth,
+		TileHeight: m.TileHeight,
+		Layers:     m.SubMeshLayerNames,
+	})
 }
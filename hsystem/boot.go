@@ -1,16 +1,22 @@
+//go:build js && wasm
+
 package hsystem
 
 import (
 	"fmt"
 	"syscall/js"
+	"time"
 
 	"github.com/qbart/hashira/hjs"
+	"github.com/qbart/hashira/hrender"
 )
 
 func Init() {
 	js.Global().Set("HashiraInitRenderLoop", js.FuncOf(InitRenderLoop))
 }
 
+// InitRenderLoop creates new Hashira instance rendering to the canvas
+// and returns its JS handle (see Instance.JsValue), or null on error.
 func InitRenderLoop(this js.Value, args []js.Value) any {
 	if len(args) != 1 && len(args) != 2 {
 		panic("Hashira render loop: expected 1 or 2 arguments - canvasID, {options}")
@@ -18,45 +24,48 @@ func InitRenderLoop(this js.Value, args []js.Value) any {
 	canvasID := args[0].String()
 	canvas := hjs.Canvas(hjs.GetElementByID(canvasID))
 	if canvas.IsNull() {
-		return fmt.Errorf("CanvasID: `%s` not found", canvasID)
+		fmt.Printf("CanvasID: `%s` not found\n", canvasID)
+		return js.Null()
 	}
 	canvas.Resize()
 
 	commands := &Commands{
 		Events: make([]*Event, 0, 10),
 	}
-	js.Global().Set("HashiraSendEvent", js.FuncOf(commands.AddEvent))
+	var renderMode hrender.Mode
+	if len(args) == 2 {
+		options := hjs.Object(args[1])
+		if options.Has("renderMode") {
+			renderMode = hrender.Mode(options.GetString("renderMode"))
+		}
+		if options.Has("maxEventsPerFrame") {
+			commands.Budget.MaxEvents = options.GetInt("maxEventsPerFrame")
+		}
+		if options.Has("eventsBudgetMs") {
+			commands.Budget.MaxDuration = time.Duration(options.GetFloat32("eventsBudgetMs") * float32(time.Millisecond))
+		}
+	}
+	outbound := NewOutbound()
 
 	app := &DefaultApp{
-		Commands: commands,
-		Canvas:   canvas,
+		Commands:   commands,
+		Outbound:   outbound,
+		Canvas:     canvas,
+		RenderMode: renderMode,
 	}
 
-	RenderLoop(app)
-	return nil
-}
-
-func RenderLoop(app App) {
-	if err := app.Init(); err != nil {
-		panic(err)
+	loop := NewRenderLoop(app)
+	if err := loop.Start(); err != nil {
+		fmt.Println("Hashira render loop:", err)
+		return js.Null()
 	}
 
-	var callback func(this js.Value, args []js.Value) interface{}
-	prevTotalDuration := 0.0
-	go func() {
-		callback = func(this js.Value, args []js.Value) interface{} {
-			// calculate delta time
-			totalDuration := args[0].Float() / 1000.0
-			deltaTime := totalDuration - prevTotalDuration
-			prevTotalDuration = totalDuration
-
-			// run single frame
-			app.Tick(float32(deltaTime))
-
-			// request next frame
-			js.Global().Call("requestAnimationFrame", js.FuncOf(callback))
-			return nil
-		}
-		callback(js.ValueOf(nil), []js.Value{js.ValueOf(0)})
-	}()
+	instance := &Instance{
+		Commands: commands,
+		Outbound: outbound,
+		App:      app,
+		Loop:     loop,
+	}
+	commands.Intercept = instance.Control
+	return instance.JsValue()
 }
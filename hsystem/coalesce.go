@@ -0,0 +1,51 @@
+package hsystem
+
+import (
+	"encoding/json"
+
+	"github.com/SoftKiwiGames/risky/risky"
+	"github.com/qbart/hashira/hsystem/hevents"
+)
+
+// coalesce merges next event into the last queued event when the result is the same
+// as processing both of them and reports whether it did.
+func coalesce(last *Event, next *Event) bool {
+	if last.Type != next.Type || last.Binary || next.Binary {
+		return false
+	}
+
+	switch next.Type {
+	case "CameraTranslatedBy":
+		a := risky.JSON[hevents.CameraTranslatedBy](last.Payload)
+		b := risky.JSON[hevents.CameraTranslatedBy](next.Payload)
+		payload, err := json.Marshal(hevents.CameraTranslatedBy{
+			X: a.X + b.X,
+			Y: a.Y + b.Y,
+		})
+		if err != nil {
+			return false
+		}
+		last.Payload = payload
+		return true
+
+	case "CameraDragged":
+		a := risky.JSON[hevents.CameraDragged](last.Payload)
+		b := risky.JSON[hevents.CameraDragged](next.Payload)
+		payload, err := json.Marshal(hevents.CameraDragged{
+			X: a.X + b.X,
+			Y: a.Y + b.Y,
+		})
+		if err != nil {
+			return false
+		}
+		last.Payload = payload
+		return true
+
+	// only the last one matters
+	case "CameraTranslated", "CameraZoomed", "PointerMoved":
+		last.Payload = next.Payload
+		return true
+	}
+
+	return false
+}
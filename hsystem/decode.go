@@ -0,0 +1,23 @@
+package hsystem
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/SoftKiwiGames/risky/risky"
+)
+
+// decodeEvent decodes payload of events that support both JSON and binary transport.
+func decodeEvent[T any, P interface {
+	*T
+	encoding.BinaryUnmarshaler
+}](event *Event) (*T, error) {
+	if !event.Binary {
+		return risky.JSON[T](event.Payload), nil
+	}
+	var data T
+	if err := P(&data).UnmarshalBinary(event.Payload); err != nil {
+		return nil, fmt.Errorf("error decoding binary event: %v", err)
+	}
+	return &data, nil
+}
@@ -2,30 +2,74 @@ package hsystem
 
 import (
 	"sync"
-	"syscall/js"
+	"time"
 )
 
 type Event struct {
 	Type    string
 	Payload []byte
+	// payload is binary encoded instead of JSON
+	Binary bool
+}
+
+// DefaultMaxEvents is used when Budget.MaxEvents is 0.
+const DefaultMaxEvents = 1000
+
+// Budget limits how many queued events are processed in a single frame.
+// Negative MaxEvents means no limit, zero MaxDuration means no time limit.
+type Budget struct {
+	MaxEvents   int
+	MaxDuration time.Duration
+}
+
+func (b Budget) Exceeded(processed int, elapsed time.Duration) bool {
+	maxEvents := b.MaxEvents
+	if maxEvents == 0 {
+		maxEvents = DefaultMaxEvents
+	}
+	if maxEvents > 0 && processed >= maxEvents {
+		return true
+	}
+	if b.MaxDuration > 0 && elapsed >= b.MaxDuration {
+		return true
+	}
+	return false
 }
 
 type Commands struct {
 	sync.RWMutex
 	Events []*Event
+	Budget Budget
+	// Intercept handles events immediately instead of queueing them
+	// when it returns true, used for events that must work while app is not ticking.
+	Intercept func(event *Event) bool
 }
 
-func (c *Commands) AddEvent(this js.Value, args []js.Value) any {
-	c.Lock()
-	id := args[0].String()
-	data := args[1].String()
-	c.Events = append(c.Events, &Event{
+// add must be called with lock held.
+func (c *Commands) add(id string, data string) {
+	c.push(&Event{
 		Type:    id,
 		Payload: []byte(data),
 	})
-	defer c.Unlock()
+}
 
-	return js.Null()
+// addBinary must be called with lock held.
+func (c *Commands) addBinary(id string, data []byte) {
+	c.push(&Event{
+		Type:    id,
+		Payload: data,
+		Binary:  true,
+	})
+}
+
+func (c *Commands) push(event *Event) {
+	if c.Intercept != nil && c.Intercept(event) {
+		return
+	}
+	if n := len(c.Events); n > 0 && coalesce(c.Events[n-1], event) {
+		return
+	}
+	c.Events = append(c.Events, event)
 }
 
 func (c *Commands) HasEvents() bool {
@@ -34,6 +78,12 @@ func (c *Commands) HasEvents() bool {
 	return len(c.Events) > 0
 }
 
+func (c *Commands) Len() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.Events)
+}
+
 func (c *Commands) PeekEvent() *Event {
 	c.Lock()
 	defer c.Unlock()
@@ -0,0 +1,45 @@
+//go:build js && wasm
+
+package hsystem
+
+import (
+	"syscall/js"
+
+	"github.com/qbart/hashira/hjs"
+)
+
+func (c *Commands) AddEvent(this js.Value, args []js.Value) any {
+	c.Lock()
+	defer c.Unlock()
+	c.add(args[0].String(), args[1].String())
+
+	return js.Null()
+}
+
+// AddBinaryEvent accepts type and Uint8Array payload.
+func (c *Commands) AddBinaryEvent(this js.Value, args []js.Value) any {
+	c.Lock()
+	defer c.Unlock()
+	c.addBinary(args[0].String(), hjs.CopyBytes(args[1]))
+
+	return js.Null()
+}
+
+// AddEvents accepts array of [type, payload] pairs,
+// payload is either JSON string or Uint8Array.
+func (c *Commands) AddEvents(this js.Value, args []js.Value) any {
+	c.Lock()
+	defer c.Unlock()
+	events := args[0]
+	for i := 0; i < events.Length(); i++ {
+		event := events.Index(i)
+		payload := event.Index(1)
+		if payload.Type() == js.TypeString {
+			c.add(event.Index(0).String(), payload.String())
+		} else {
+			c.addBinary(event.Index(0).String(), hjs.CopyBytes(payload))
+		}
+	}
+
+	return js.Null()
+}
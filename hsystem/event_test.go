@@ -0,0 +1,138 @@
+package hsystem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SoftKiwiGames/risky/risky"
+	"github.com/qbart/hashira/hsystem/hevents"
+)
+
+func TestCommandsCoalesceCameraTranslatedBy(t *testing.T) {
+	c := &Commands{}
+	c.add("CameraTranslatedBy", `{"x":1,"y":2}`)
+	c.add("CameraTranslatedBy", `{"x":3,"y":-5}`)
+
+	if got := c.Len(); got != 1 {
+		t.Fatalf("got %d events, want 1", got)
+	}
+	data := risky.JSON[hevents.CameraTranslatedBy](c.PeekEvent().Payload)
+	if data.X != 4 || data.Y != -3 {
+		t.Errorf("got %+v, want {X:4 Y:-3}", data)
+	}
+}
+
+func TestCommandsCoalesceCameraDragged(t *testing.T) {
+	c := &Commands{}
+	c.add("CameraDragged", `{"x":1,"y":2}`)
+	c.add("CameraDragged", `{"x":3,"y":-5}`)
+
+	if got := c.Len(); got != 1 {
+		t.Fatalf("got %d events, want 1", got)
+	}
+	data := risky.JSON[hevents.CameraDragged](c.PeekEvent().Payload)
+	if data.X != 4 || data.Y != -3 {
+		t.Errorf("got %+v, want {X:4 Y:-3}", data)
+	}
+}
+
+func TestCommandsCoalesceLastWins(t *testing.T) {
+	c := &Commands{}
+	c.add("CameraZoomed", `{"zoom":2}`)
+	c.add("CameraZoomed", `{"zoom":3}`)
+	c.add("TileAssigned", `{"x":1}`)
+	c.add("TileAssigned", `{"x":2}`)
+	c.add("CameraZoomed", `{"zoom":4}`)
+
+	want := []string{`{"zoom":3}`, `{"x":1}`, `{"x":2}`, `{"zoom":4}`}
+	if got := c.Len(); got != len(want) {
+		t.Fatalf("got %d events, want %d", got, len(want))
+	}
+	for _, payload := range want {
+		if got := string(c.PeekEvent().Payload); got != payload {
+			t.Errorf("got payload %s, want %s", got, payload)
+		}
+	}
+}
+
+func TestCommandsDoNotCoalesceBinary(t *testing.T) {
+	c := &Commands{}
+	c.addBinary("CameraZoomed", []byte{1})
+	c.addBinary("CameraZoomed", []byte{2})
+
+	if got := c.Len(); got != 2 {
+		t.Errorf("got %d events, want 2", got)
+	}
+}
+
+func TestCommandsIntercept(t *testing.T) {
+	intercepted := []string{}
+	c := &Commands{
+		Intercept: func(event *Event) bool {
+			if event.Type == "RenderingPaused" {
+				intercepted = append(intercepted, event.Type)
+				return true
+			}
+			return false
+		},
+	}
+	c.add("RenderingPaused", `{}`)
+	c.add("CameraZoomed", `{"zoom":2}`)
+
+	if len(intercepted) != 1 {
+		t.Errorf("got %d intercepted events, want 1", len(intercepted))
+	}
+	if got := c.Len(); got != 1 {
+		t.Errorf("got %d queued events, want 1", got)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	tests := []struct {
+		budget    Budget
+		processed int
+		elapsed   time.Duration
+		exceeded  bool
+	}{
+		{Budget{}, DefaultMaxEvents - 1, time.Second, false},
+		{Budget{}, DefaultMaxEvents, 0, true},
+		{Budget{MaxEvents: -1}, 100 * DefaultMaxEvents, time.Second, false},
+		{Budget{MaxEvents: 10}, 9, 0, false},
+		{Budget{MaxEvents: 10}, 10, 0, true},
+		{Budget{MaxDuration: time.Millisecond}, 1, 500 * time.Microsecond, false},
+		{Budget{MaxDuration: time.Millisecond}, 1, time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.budget.Exceeded(tt.processed, tt.elapsed); got != tt.exceeded {
+			t.Errorf("%+v with %d events after %v: got %v, want %v", tt.budget, tt.processed, tt.elapsed, got, tt.exceeded)
+		}
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	jsonEvent := &Event{Type: "TileAssigned", Payload: []byte(`{"map":"m","layer":"l","x":1,"y":2,"tile":3}`)}
+	binaryEvent := &Event{Type: "TileAssigned", Binary: true, Payload: []byte{
+		1, 0, 0, 0, 'm',
+		1, 0, 0, 0, 'l',
+		1, 0, 0, 0,
+		2, 0, 0, 0,
+		3, 0, 0, 0,
+	}}
+	want := hevents.TileAssigned{Map: "m", Layer: "l", X: 1, Y: 2, Tile: 3}
+
+	for _, event := range []*Event{jsonEvent, binaryEvent} {
+		data, err := decodeEvent[hevents.TileAssigned](event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *data != want {
+			t.Errorf("binary %v: got %+v, want %+v", event.Binary, *data, want)
+		}
+	}
+
+	binaryEvent.Payload = binaryEvent.Payload[:10]
+	if _, err := decodeEvent[hevents.TileAssigned](binaryEvent); err == nil {
+		t.Error("expected error for truncated payload")
+	}
+}
@@ -0,0 +1,9 @@
+package hevents
+
+type AnimationDefined struct {
+	Frames []int   `json:"frames,omitempty"`
+	Delay  float32 `json:"delay,omitempty"`
+}
+
+type AnimationsCleared struct {
+}
@@ -0,0 +1,121 @@
+package hevents
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Binary payloads are little endian:
+//
+//	string - uint32 length followed by UTF-8 bytes
+//	int    - int32
+//	bytes  - all remaining bytes of the payload
+//
+// Only events implementing encoding.BinaryUnmarshaler can be sent through binary transport.
+
+var errShortPayload = errors.New("binary payload too short")
+
+type binaryReader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b)-r.off < n {
+		r.err = errShortPayload
+		return nil
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *binaryReader) Int() int {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return int(int32(binary.LittleEndian.Uint32(b)))
+}
+
+func (r *binaryReader) String() string {
+	n := r.Int()
+	return string(r.next(n))
+}
+
+func (r *binaryReader) Rest() []byte {
+	return r.next(len(r.b) - r.off)
+}
+
+func (r *binaryReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.off != len(r.b) {
+		return fmt.Errorf("binary payload has %d unexpected trailing bytes", len(r.b)-r.off)
+	}
+	return nil
+}
+
+// UnmarshalBinary layout: string name, int margin, int spacing, int columns, int tile count, bytes png.
+func (e *TilesetLoaded) UnmarshalBinary(b []byte) error {
+	r := &binaryReader{b: b}
+	e.Name = r.String()
+	e.Margin = r.Int()
+	e.Spacing = r.Int()
+	e.Columns = r.Int()
+	e.TileCount = r.Int()
+	e.Bytes = r.Rest()
+	return r.Err()
+}
+
+// UnmarshalBinary layout: string name, bytes tmx or tmj file.
+func (e *TiledMapLoaded) UnmarshalBinary(b []byte) error {
+	r := &binaryReader{b: b}
+	e.Name = r.String()
+	e.Bytes = r.Rest()
+	return r.Err()
+}
+
+// UnmarshalBinary layout: bytes world file.
+func (e *WorldLoaded) UnmarshalBinary(b []byte) error {
+	r := &binaryReader{b: b}
+	e.Bytes = r.Rest()
+	return r.Err()
+}
+
+// UnmarshalBinary layout: string map, string layer, int width, int height, int tile * width * height (rows top to bottom).
+func (e *LayerDataAdded) UnmarshalBinary(b []byte) error {
+	r := &binaryReader{b: b}
+	e.Map = r.String()
+	e.Layer = r.String()
+	width := r.Int()
+	height := r.Int()
+	if r.err == nil && (width < 0 || height < 0 || width*height*4 > len(b)-r.off) {
+		return errShortPayload
+	}
+	e.Data = make([][]int, height)
+	for y := range e.Data {
+		e.Data[y] = make([]int, width)
+		for x := range e.Data[y] {
+			e.Data[y][x] = r.Int()
+		}
+	}
+	return r.Err()
+}
+
+// UnmarshalBinary layout: string map, string layer, int x, int y, int tile.
+func (e *TileAssigned) UnmarshalBinary(b []byte) error {
+	r := &binaryReader{b: b}
+	e.Map = r.String()
+	e.Layer = r.String()
+	e.X = r.Int()
+	e.Y = r.Int()
+	e.Tile = r.Int()
+	return r.Err()
+}
@@ -0,0 +1,75 @@
+package hevents
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type payloadWriter struct {
+	bytes.Buffer
+}
+
+func (w *payloadWriter) Int(v int) *payloadWriter {
+	binary.Write(&w.Buffer, binary.LittleEndian, int32(v))
+	return w
+}
+
+func (w *payloadWriter) String(s string) *payloadWriter {
+	w.Int(len(s))
+	w.WriteString(s)
+	return w
+}
+
+func TestTilesetLoadedUnmarshalBinary(t *testing.T) {
+	w := &payloadWriter{}
+	w.String("terrain").Int(1).Int(2).Int(8).Int(60)
+	w.Write([]byte{0x89, 'P', 'N', 'G'})
+
+	var e TilesetLoaded
+	if err := e.UnmarshalBinary(w.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Name != "terrain" || !bytes.Equal(e.Bytes, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("got %+v", e)
+	}
+	if e.Margin != 1 || e.Spacing != 2 || e.Columns != 8 || e.TileCount != 60 {
+		t.Errorf("got layout %+v", e)
+	}
+}
+
+func TestLayerDataAddedUnmarshalBinary(t *testing.T) {
+	w := &payloadWriter{}
+	w.String("island").String("grass").Int(3).Int(2)
+	for _, tile := range []int{1, 2, 3, 4, -1, 600} {
+		w.Int(tile)
+	}
+
+	var e LayerDataAdded
+	if err := e.UnmarshalBinary(w.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := LayerDataAdded{Map: "island", Layer: "grass", Data: [][]int{{1, 2, 3}, {4, -1, 600}}}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("got %+v, want %+v", e, want)
+	}
+}
+
+func TestLayerDataAddedUnmarshalBinaryErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"missing tiles":  (&payloadWriter{}).String("m").String("l").Int(2).Int(2).Int(1).Bytes(),
+		"trailing bytes": append((&payloadWriter{}).String("m").String("l").Int(1).Int(1).Int(1).Bytes(), 0),
+		"huge size":      (&payloadWriter{}).String("m").String("l").Int(1 << 20).Int(1 << 20).Bytes(),
+		"short string":   (&payloadWriter{}).Int(10).Bytes(),
+	}
+
+	for name, payload := range tests {
+		var e LayerDataAdded
+		if err := e.UnmarshalBinary(payload); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -10,6 +10,11 @@ type CameraTranslatedBy struct {
 	Y float32 `json:"y,omitempty"`
 }
 
+type CameraDragged struct {
+	X float32 `json:"x,omitempty"`
+	Y float32 `json:"y,omitempty"`
+}
+
 type CameraZoomed struct {
 	Zoom float32 `json:"zoom,omitempty"`
 }
@@ -21,3 +26,32 @@ type CameraZoomedBy struct {
 type CameraTranslatedToMapCenter struct {
 	Map string `json:"map,omitempty"`
 }
+
+type CameraPixelPerfectSet struct {
+	Enabled  bool    `json:"enabled,omitempty"`
+	ZoomStep float32 `json:"zoom_step,omitempty"`
+}
+
+type CameraMovedTo struct {
+	X    float32 `json:"x,omitempty"`
+	Y    float32 `json:"y,omitempty"`
+	Zoom float32 `json:"zoom,omitempty"`
+	// seconds
+	Duration float32 `json:"duration,omitempty"`
+	Easing   string  `json:"easing,omitempty"`
+}
+
+type CameraFollowed struct {
+	X     float32 `json:"x,omitempty"`
+	Y     float32 `json:"y,omitempty"`
+	Speed float32 `json:"speed,omitempty"`
+}
+
+type CameraBoundsSet struct {
+	// bounds of the map when set
+	Map  string  `json:"map,omitempty"`
+	MinX float32 `json:"min_x,omitempty"`
+	MinY float32 `json:"min_y,omitempty"`
+	MaxX float32 `json:"max_x,omitempty"`
+	MaxY float32 `json:"max_y,omitempty"`
+}
@@ -9,9 +9,10 @@ type MapAdded struct {
 }
 
 type LayerAdded struct {
-	Map  string `json:"map,omitempty"`
-	Name string
-	Z    float32
+	Map     string `json:"map,omitempty"`
+	Name    string
+	Z       float32
+	Tileset string `json:"tileset,omitempty"`
 }
 
 type LayerDataAdded struct {
@@ -20,6 +21,7 @@ type LayerDataAdded struct {
 	Data  [][]int `json:"data,omitempty"`
 }
 
+// TileAssigned sets a single tile, Tile can carry flip flags (see hashira.TileFlipHorizontal).
 type TileAssigned struct {
 	Map   string `json:"map,omitempty"`
 	Layer string `json:"layer,omitempty"`
@@ -27,3 +29,8 @@ type TileAssigned struct {
 	Y     int    `json:"y,omitempty"`
 	Tile  int    `json:"tile,omitempty"`
 }
+
+type TiledMapLoaded struct {
+	Name  string `json:"name,omitempty"`
+	Bytes []byte `json:"bytes,omitempty"`
+}
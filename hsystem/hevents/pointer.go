@@ -0,0 +1,31 @@
+package hevents
+
+// Pointer positions are in CSS pixels relative to the canvas.
+
+type PointerMoved struct {
+	X float32 `json:"x,omitempty"`
+	Y float32 `json:"y,omitempty"`
+}
+
+type PointerClicked struct {
+	X float32 `json:"x,omitempty"`
+	Y float32 `json:"y,omitempty"`
+}
+
+// outbound
+
+type TileHovered struct {
+	Map   string `json:"map"`
+	Layer string `json:"layer"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Tile  int    `json:"tile"`
+}
+
+type TileClicked struct {
+	Map   string `json:"map"`
+	Layer string `json:"layer"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Tile  int    `json:"tile"`
+}
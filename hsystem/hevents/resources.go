@@ -1,5 +1,31 @@
 package hevents
 
+// TilesetLoaded carries PNG image and layout of its tiles (see hashira.TilesetLayout).
 type TilesetLoaded struct {
-	Bytes []byte `json:"bytes,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Margin    int    `json:"margin,omitempty"`
+	Spacing   int    `json:"spacing,omitempty"`
+	Columns   int    `json:"columns,omitempty"`
+	TileCount int    `json:"tile_count,omitempty"`
+	Bytes     []byte `json:"bytes,omitempty"`
+}
+
+// TilesetsPacked packs loaded tilesets into a single atlas texture named Name.
+type TilesetsPacked struct {
+	Name       string   `json:"name,omitempty"`
+	Tilesets   []string `json:"tilesets,omitempty"`
+	TileWidth  int      `json:"tile_width,omitempty"`
+	TileHeight int      `json:"tile_height,omitempty"`
+	Extrude    int      `json:"extrude,omitempty"`
+}
+
+// TextureFilteringSet changes sampling of a loaded image (tileset or atlas),
+// empty Texture changes defaults used by all images without own options.
+type TextureFilteringSet struct {
+	Texture string `json:"texture,omitempty"`
+	// "nearest" (default) or "linear"
+	Filter  string `json:"filter,omitempty"`
+	Mipmaps bool   `json:"mipmaps,omitempty"`
+	// "clamp" (default), "repeat" or "mirror"
+	Wrap string `json:"wrap,omitempty"`
 }
@@ -0,0 +1,33 @@
+package hevents
+
+// outbound
+
+type ErrorOccurred struct {
+	// inbound event that caused the error, empty for errors found while updating the frame
+	Event   string `json:"event"`
+	Message string `json:"message"`
+}
+
+type TilesetReady struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type MapReady struct {
+	Name       string   `json:"name"`
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	TileWidth  int      `json:"tile_width"`
+	TileHeight int      `json:"tile_height"`
+	Layers     []string `json:"layers"`
+}
+
+type FrameStats struct {
+	FPS          float32 `json:"fps"`
+	FrameTime    float32 `json:"frame_time"`
+	QueuedEvents int     `json:"queued_events"`
+	// last frame only, chunks outside of the camera are culled
+	ChunksDrawn int `json:"chunks_drawn"`
+	TilesDrawn  int `json:"tiles_drawn"`
+}
@@ -0,0 +1,9 @@
+package hevents
+
+type WorldExported struct {
+	Format string `json:"format,omitempty"`
+}
+
+type WorldLoaded struct {
+	Bytes []byte `json:"bytes,omitempty"`
+}
@@ -0,0 +1,68 @@
+//go:build js && wasm
+
+package hsystem
+
+import (
+	"syscall/js"
+)
+
+// Instance is a single Hashira runtime bound to a canvas
+// with its own commands queue, outbound events and render loop.
+type Instance struct {
+	Commands *Commands
+	Outbound *Outbound
+	App      App
+	Loop     *RenderLoop
+
+	funcs     []js.Func
+	destroyed bool
+}
+
+// Control handles events managing the render loop,
+// they are not queued since paused app does not process events.
+func (i *Instance) Control(event *Event) bool {
+	switch event.Type {
+	case "RenderingPaused":
+		i.Loop.Pause()
+	case "RenderingResumed":
+		i.Loop.Resume()
+	case "RenderingStopped":
+		i.Destroy(js.Null(), nil)
+	default:
+		return false
+	}
+	return true
+}
+
+// JsValue returns handle used by JS client to talk to this instance.
+func (i *Instance) JsValue() js.Value {
+	return js.ValueOf(map[string]any{
+		"sendEvent":       i.funcOf(i.Commands.AddEvent),
+		"sendEvents":      i.funcOf(i.Commands.AddEvents),
+		"sendBinaryEvent": i.funcOf(i.Commands.AddBinaryEvent),
+		"onEvent":         i.funcOf(i.Outbound.Register),
+		"destroy":         i.funcOf(i.Destroy),
+	})
+}
+
+// Destroy stops rendering, releases GL objects and JS functions of the handle.
+func (i *Instance) Destroy(this js.Value, args []js.Value) any {
+	if i.destroyed {
+		return js.Null()
+	}
+	i.destroyed = true
+	i.Loop.Stop()
+	i.App.Shutdown()
+	for _, fn := range i.funcs {
+		fn.Release()
+	}
+	i.funcs = nil
+
+	return js.Null()
+}
+
+func (i *Instance) funcOf(fn func(this js.Value, args []js.Value) any) js.Func {
+	f := js.FuncOf(fn)
+	i.funcs = append(i.funcs, f)
+	return f
+}
@@ -0,0 +1,110 @@
+//go:build js && wasm
+
+package hsystem
+
+import (
+	"syscall/js"
+)
+
+// RenderLoop calls App.Tick on every animation frame
+// using a single JS callback for the whole lifetime of the loop.
+type RenderLoop struct {
+	app      App
+	callback js.Func
+	frameID  js.Value
+	// previous frame timestamp in seconds, negative when unknown
+	prevTime float64
+	paused   bool
+	stopped  bool
+}
+
+func NewRenderLoop(app App) *RenderLoop {
+	return &RenderLoop{
+		app:      app,
+		frameID:  js.Null(),
+		prevTime: -1,
+	}
+}
+
+func (l *RenderLoop) Start() error {
+	if err := l.app.Init(); err != nil {
+		return err
+	}
+	l.callback = js.FuncOf(l.frame)
+	l.requestFrame()
+
+	return nil
+}
+
+// Pause stops rendering until Resume is called.
+func (l *RenderLoop) Pause() {
+	if l.stopped || l.paused {
+		return
+	}
+	l.paused = true
+	l.cancelFrame()
+}
+
+func (l *RenderLoop) Resume() {
+	if l.stopped || !l.paused {
+		return
+	}
+	l.paused = false
+	// don't count paused time as frame time
+	l.prevTime = -1
+	l.requestFrame()
+}
+
+// Stop cancels scheduled frame and releases the callback,
+// no more frames are rendered after this call.
+func (l *RenderLoop) Stop() {
+	if l.stopped {
+		return
+	}
+	l.stopped = true
+	l.cancelFrame()
+	l.callback.Release()
+}
+
+func (l *RenderLoop) IsPaused() bool {
+	return l.paused
+}
+
+func (l *RenderLoop) IsStopped() bool {
+	return l.stopped
+}
+
+func (l *RenderLoop) frame(this js.Value, args []js.Value) any {
+	l.frameID = js.Null()
+	if l.stopped || l.paused {
+		return nil
+	}
+
+	// calculate delta time
+	totalDuration := args[0].Float() / 1000.0
+	deltaTime := 0.0
+	if l.prevTime >= 0 {
+		deltaTime = totalDuration - l.prevTime
+	}
+	l.prevTime = totalDuration
+
+	// run single frame
+	l.app.Tick(float32(deltaTime))
+
+	// app could be stopped while processing events
+	if !l.stopped && !l.paused {
+		l.requestFrame()
+	}
+	return nil
+}
+
+func (l *RenderLoop) requestFrame() {
+	l.frameID = js.Global().Call("requestAnimationFrame", l.callback)
+}
+
+func (l *RenderLoop) cancelFrame() {
+	if !l.frameID.IsNull() {
+		js.Global().Call("cancelAnimationFrame", l.frameID)
+		l.frameID = js.Null()
+	}
+}
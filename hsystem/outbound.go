@@ -0,0 +1,72 @@
+//go:build js && wasm
+
+package hsystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"syscall/js"
+
+	"github.com/qbart/hashira/hjs"
+)
+
+// Outbound sends events from Go to JS
+// through a callback registered with onEvent(fn) of the instance handle.
+// Callback is called with (eventType, payload).
+type Outbound struct {
+	sync.RWMutex
+	callback js.Value
+}
+
+func NewOutbound() *Outbound {
+	return &Outbound{
+		callback: js.Undefined(),
+	}
+}
+
+func (o *Outbound) Register(this js.Value, args []js.Value) any {
+	o.Lock()
+	defer o.Unlock()
+	if len(args) != 1 || args[0].Type() != js.TypeFunction {
+		o.callback = js.Undefined()
+		return js.Null()
+	}
+	o.callback = args[0]
+
+	return js.Null()
+}
+
+func (o *Outbound) HasCallback() bool {
+	o.RLock()
+	defer o.RUnlock()
+	return o.callback.Type() == js.TypeFunction
+}
+
+// Emit sends event with payload encoded as JSON string, same as inbound events.
+func (o *Outbound) Emit(eventType string, payload any) {
+	if !o.HasCallback() {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Println("Error encoding event: ", eventType, err)
+		return
+	}
+	o.invoke(eventType, string(data))
+}
+
+// EmitBytes sends event with payload passed as Uint8Array.
+func (o *Outbound) EmitBytes(eventType string, payload []byte) {
+	if !o.HasCallback() {
+		return
+	}
+	o.invoke(eventType, hjs.NewUInt8Array(payload))
+}
+
+func (o *Outbound) invoke(eventType string, payload any) {
+	o.RLock()
+	callback := o.callback
+	o.RUnlock()
+	callback.Invoke(eventType, payload)
+}
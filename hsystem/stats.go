@@ -0,0 +1,34 @@
+package hsystem
+
+// frameStats accumulates frame times and reports once per second.
+type frameStats struct {
+	frames  int
+	elapsed float32
+}
+
+// Update returns true when stats should be reported.
+func (s *frameStats) Update(dt float32) bool {
+	s.frames++
+	s.elapsed += dt
+	return s.elapsed >= 1
+}
+
+func (s *frameStats) FPS() float32 {
+	if s.elapsed == 0 {
+		return 0
+	}
+	return float32(s.frames) / s.elapsed
+}
+
+// FrameTime returns average frame time in milliseconds.
+func (s *frameStats) FrameTime() float32 {
+	if s.frames == 0 {
+		return 0
+	}
+	return s.elapsed / float32(s.frames) * 1000
+}
+
+func (s *frameStats) Reset() {
+	s.frames = 0
+	s.elapsed = 0
+}
@@ -0,0 +1,71 @@
+package htiled
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func decodeCSV(s string) ([]GID, error) {
+	fields := strings.Split(strings.TrimSpace(s), ",")
+	data := make([]GID, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding csv: %v", err)
+		}
+		data = append(data, GID(v))
+	}
+	return data, nil
+}
+
+func decodeBase64(s string, compression string) ([]GID, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64: %v", err)
+	}
+
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding zlib: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gzip: %v", err)
+		}
+		defer gr.Close()
+		r = gr
+	default:
+		return nil, fmt.Errorf("unsupported compression: %v", compression)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing layer data: %v", err)
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("invalid layer data length: %v", len(b))
+	}
+	data := make([]GID, len(b)/4)
+	for i := range data {
+		data[i] = GID(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return data, nil
+}
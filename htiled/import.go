@@ -0,0 +1,103 @@
+package htiled
+
+import (
+	"fmt"
+
+	"github.com/qbart/hashira/hashira"
+)
+
+// EmptyTile is assigned to cells that have no tile in Tiled,
+// the world hides their quads so lower layers stay visible.
+const EmptyTile = hashira.EmptyTile
+
+// Import parses Tiled map and adds it to the world under given name.
+func Import(w *hashira.World, name string, data []byte) error {
+	m, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	return AddToWorld(w, name, m)
+}
+
+// AddToWorld adds map with all its tile layers to the world.
+// Each layer is bound to a single tileset, tile ids are relative to the tileset (firstgid is subtracted).
+// Tiles outside of already loaded tilesets are reported as errors (the map is added anyway).
+// Flip flags are converted to hashira tile flags, hexagonal rotation is not supported and is dropped.
+// Layouts of embedded tilesets are set in world resources.
+func AddToWorld(w *hashira.World, name string, m *Map) error {
+	tilesets := make([]string, len(m.Layers))
+	layersData := make([][][]int, len(m.Layers))
+
+	for i, l := range m.Layers {
+		var tileset *Tileset
+		data := make([][]int, l.Height)
+		for y := range data {
+			data[y] = make([]int, l.Width)
+			for x := range data[y] {
+				gid := l.At(x, y)
+				if gid.IsEmpty() {
+					data[y][x] = EmptyTile
+					continue
+				}
+				t := m.TilesetFor(gid)
+				if t == nil {
+					return fmt.Errorf("layer %q: no tileset for gid %v", l.Name, gid.ID())
+				}
+				if tileset == nil {
+					tileset = t
+				}
+				if t != tileset {
+					return fmt.Errorf("layer %q: mixing tilesets in a single layer is not supported", l.Name)
+				}
+				data[y][x] = int(gid.ID()-t.FirstGID) | tileFlags(gid)
+			}
+		}
+		if tileset != nil {
+			tilesets[i] = tileset.TilesetName()
+		}
+		layersData[i] = data
+	}
+
+	for _, t := range m.Tilesets {
+		if layout := t.Layout(); layout != (hashira.TilesetLayout{}) {
+			if err := layout.Validate(); err != nil {
+				return fmt.Errorf("tileset %q: %v", t.TilesetName(), err)
+			}
+			w.Resources.SetTilesetLayout(t.TilesetName(), layout)
+		}
+	}
+
+	w.AddMap(name, m.Width, m.Height, m.TileWidth, m.TileHeight)
+	for i, l := range m.Layers {
+		w.AddLayer(name, l.Name, float32(i), tilesets[i])
+		if err := w.AddLayerData(name, l.Name, layersData[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Layout returns placement of tiles in the tileset image.
+func (t *Tileset) Layout() hashira.TilesetLayout {
+	return hashira.TilesetLayout{
+		Margin:    t.Margin,
+		Spacing:   t.Spacing,
+		Columns:   t.Columns,
+		TileCount: t.TileCount,
+	}
+}
+
+func tileFlags(gid GID) int {
+	flags := 0
+	if gid.FlippedHorizontally() {
+		flags |= hashira.TileFlipHorizontal
+	}
+	if gid.FlippedVertically() {
+		flags |= hashira.TileFlipVertical
+	}
+	if gid.FlippedDiagonally() {
+		flags |= hashira.TileFlipDiagonal
+	}
+	return flags
+}
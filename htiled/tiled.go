@@ -0,0 +1,139 @@
+// Package htiled imports maps authored in Tiled (https://www.mapeditor.org)
+// from both JSON (.tmj) and XML (.tmx) formats.
+package htiled
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	FlippedHorizontally uint32 = 0x80000000
+	FlippedVertically   uint32 = 0x40000000
+	FlippedDiagonally   uint32 = 0x20000000
+	RotatedHexagonal120 uint32 = 0x10000000
+
+	flagsMask = FlippedHorizontally | FlippedVertically | FlippedDiagonally | RotatedHexagonal120
+)
+
+// GID is a global tile id with flip flags stored in the highest bits.
+// Zero means empty cell.
+type GID uint32
+
+func (g GID) ID() uint32 {
+	return uint32(g) &^ flagsMask
+}
+
+func (g GID) Flags() uint32 {
+	return uint32(g) & flagsMask
+}
+
+func (g GID) IsEmpty() bool {
+	return g.ID() == 0
+}
+
+func (g GID) FlippedHorizontally() bool {
+	return uint32(g)&FlippedHorizontally != 0
+}
+
+func (g GID) FlippedVertically() bool {
+	return uint32(g)&FlippedVertically != 0
+}
+
+func (g GID) FlippedDiagonally() bool {
+	return uint32(g)&FlippedDiagonally != 0
+}
+
+type Map struct {
+	Width      int
+	Height     int
+	TileWidth  int
+	TileHeight int
+	Tilesets   []*Tileset
+	// tile layers in draw order (bottom first), groups are flattened
+	Layers []*Layer
+}
+
+type Tileset struct {
+	FirstGID uint32
+	Name     string
+	// source of external tileset (.tsx/.tsj), empty for embedded tilesets
+	Source string
+	// image of embedded tileset
+	Image string
+	// layout of embedded tileset, zero for external tilesets
+	Margin    int
+	Spacing   int
+	Columns   int
+	TileCount int
+}
+
+type Layer struct {
+	Name   string
+	Width  int
+	Height int
+	// row major, top row first
+	Data []GID
+}
+
+func (l *Layer) At(x, y int) GID {
+	return l.Data[y*l.Width+x]
+}
+
+// Parse detects format of the data and parses Tiled map.
+func Parse(data []byte) (*Map, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty tiled map")
+	}
+	if trimmed[0] == '<' {
+		return ParseTMX(trimmed)
+	}
+	return ParseTMJ(trimmed)
+}
+
+// TilesetName returns name used to reference the tileset in Hashira.
+// For external tilesets it's the source file name without extension.
+func (t *Tileset) TilesetName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	base := path.Base(t.Source)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// TilesetFor finds tileset that owns given gid.
+func (m *Map) TilesetFor(gid GID) *Tileset {
+	var found *Tileset
+	id := gid.ID()
+	for _, t := range m.Tilesets {
+		if t.FirstGID <= id && (found == nil || t.FirstGID > found.FirstGID) {
+			found = t
+		}
+	}
+	return found
+}
+
+func (m *Map) validate() error {
+	for _, l := range m.Layers {
+		if l.Width != m.Width || l.Height != m.Height {
+			return fmt.Errorf("layer %q: size %dx%d does not match map size %dx%d", l.Name, l.Width, l.Height, m.Width, m.Height)
+		}
+		if len(l.Data) != l.Width*l.Height {
+			return fmt.Errorf("layer %q: expected %d tiles, got %d", l.Name, l.Width*l.Height, len(l.Data))
+		}
+	}
+	return nil
+}
+
+func checkOrientation(orientation string, infinite bool) error {
+	if orientation != "" && orientation != "orthogonal" {
+		return fmt.Errorf("unsupported orientation: %v", orientation)
+	}
+	if infinite {
+		return fmt.Errorf("infinite maps are not supported")
+	}
+	return nil
+}
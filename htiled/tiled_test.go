@@ -0,0 +1,239 @@
+package htiled
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qbart/hashira/hashira"
+)
+
+func encodeGIDs(t *testing.T, gids []uint32, compression string) string {
+	t.Helper()
+
+	var raw bytes.Buffer
+	for _, gid := range gids {
+		if err := binary.Write(&raw, binary.LittleEndian, gid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	switch compression {
+	case "":
+		out = raw
+	case "zlib":
+		w := zlib.NewWriter(&out)
+		w.Write(raw.Bytes())
+		w.Close()
+	case "gzip":
+		w := gzip.NewWriter(&out)
+		w.Write(raw.Bytes())
+		w.Close()
+	}
+	return base64.StdEncoding.EncodeToString(out.Bytes())
+}
+
+func TestParseTMX(t *testing.T) {
+	gids := []uint32{1, 2, 3, FlippedHorizontally | 4}
+	tmx := `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="2" height="2" tilewidth="16" tileheight="8" infinite="0">
+ <tileset firstgid="1" source="terrain.tsx"/>
+ <tileset firstgid="100" name="props" tilewidth="16" tileheight="8" margin="1" spacing="2" columns="3" tilecount="21">
+  <image source="props.png" width="64" height="64"/>
+ </tileset>
+ <layer id="1" name="csv" width="2" height="2">
+  <data encoding="csv">
+1,0,
+100,101
+</data>
+ </layer>
+ <group id="2" name="group">
+  <layer id="3" name="zlib" width="2" height="2">
+   <data encoding="base64" compression="zlib">` + encodeGIDs(t, gids, "zlib") + `</data>
+  </layer>
+ </group>
+ <objectgroup id="4" name="objects"/>
+ <layer id="5" name="xml" width="2" height="2">
+  <data><tile gid="1"/><tile/><tile gid="2"/><tile gid="3"/></data>
+ </layer>
+</map>`
+
+	m, err := Parse([]byte(tmx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Width != 2 || m.Height != 2 || m.TileWidth != 16 || m.TileHeight != 8 {
+		t.Errorf("got map size %dx%d tile %dx%d", m.Width, m.Height, m.TileWidth, m.TileHeight)
+	}
+	wantTilesets := []*Tileset{
+		{FirstGID: 1, Source: "terrain.tsx"},
+		{FirstGID: 100, Name: "props", Image: "props.png", Margin: 1, Spacing: 2, Columns: 3, TileCount: 21},
+	}
+	if !reflect.DeepEqual(m.Tilesets, wantTilesets) {
+		t.Errorf("got tilesets %+v, want %+v", m.Tilesets, wantTilesets)
+	}
+	wantLayers := []*Layer{
+		{Name: "csv", Width: 2, Height: 2, Data: []GID{1, 0, 100, 101}},
+		{Name: "zlib", Width: 2, Height: 2, Data: []GID{1, 2, 3, GID(FlippedHorizontally | 4)}},
+		{Name: "xml", Width: 2, Height: 2, Data: []GID{1, 0, 2, 3}},
+	}
+	if !reflect.DeepEqual(m.Layers, wantLayers) {
+		t.Errorf("got layers %+v, want %+v", m.Layers, wantLayers)
+	}
+}
+
+func TestParseTMJ(t *testing.T) {
+	gids := []uint32{5, 6, 7, FlippedVertically | FlippedDiagonally | 8}
+	for _, compression := range []string{"", "zlib", "gzip"} {
+		t.Run("base64 "+compression, func(t *testing.T) {
+			tmj := `{
+				"orientation": "orthogonal", "infinite": false,
+				"width": 2, "height": 2, "tilewidth": 32, "tileheight": 32,
+				"tilesets": [
+					{"firstgid": 5, "source": "maps/terrain.tsj"},
+					{"firstgid": 100, "name": "props", "image": "props.png", "margin": 1, "spacing": 2, "columns": 3, "tilecount": 21}
+				],
+				"layers": [
+					{"type": "tilelayer", "name": "plain", "width": 2, "height": 2, "data": [5, 0, 6, 7]},
+					{"type": "group", "name": "g", "layers": [
+						{"type": "tilelayer", "name": "encoded", "width": 2, "height": 2,
+						 "encoding": "base64", "compression": "` + compression + `", "data": "` + encodeGIDs(t, gids, compression) + `"}
+					]},
+					{"type": "objectgroup", "name": "objects"}
+				]
+			}`
+
+			m, err := Parse([]byte(tmj))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantLayers := []*Layer{
+				{Name: "plain", Width: 2, Height: 2, Data: []GID{5, 0, 6, 7}},
+				{Name: "encoded", Width: 2, Height: 2, Data: []GID{5, 6, 7, GID(FlippedVertically | FlippedDiagonally | 8)}},
+			}
+			if !reflect.DeepEqual(m.Layers, wantLayers) {
+				t.Errorf("got layers %+v, want %+v", m.Layers, wantLayers)
+			}
+			if got := m.Tilesets[0].TilesetName(); got != "terrain" {
+				t.Errorf("got tileset name %q, want terrain", got)
+			}
+			want := hashira.TilesetLayout{Margin: 1, Spacing: 2, Columns: 3, TileCount: 21}
+			if got := m.Tilesets[1].Layout(); got != want {
+				t.Errorf("got layout %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":        "  ",
+		"isometric":    `{"orientation": "isometric", "width": 1, "height": 1}`,
+		"infinite":     `<map orientation="orthogonal" infinite="1"/>`,
+		"zstd":         `{"orientation": "orthogonal", "width": 1, "height": 1, "layers": [{"type": "tilelayer", "name": "l", "width": 1, "height": 1, "encoding": "base64", "compression": "zstd", "data": "AAAAAA=="}]}`,
+		"size":         `{"orientation": "orthogonal", "width": 2, "height": 1, "layers": [{"type": "tilelayer", "name": "l", "width": 2, "height": 1, "data": [1]}]}`,
+		"invalid csv":  `<map orientation="orthogonal" width="1" height="1"><layer name="l" width="1" height="1"><data encoding="csv">x</data></layer></map>`,
+		"invalid json": `{"width": `,
+	}
+
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestGIDFlags(t *testing.T) {
+	gid := GID(FlippedHorizontally | FlippedDiagonally | 42)
+
+	if gid.ID() != 42 {
+		t.Errorf("got id %d, want 42", gid.ID())
+	}
+	if !gid.FlippedHorizontally() || gid.FlippedVertically() || !gid.FlippedDiagonally() {
+		t.Errorf("got wrong flags %x", gid.Flags())
+	}
+	if !GID(FlippedVertically).IsEmpty() {
+		t.Error("gid with only flags should be empty")
+	}
+}
+
+func TestAddToWorld(t *testing.T) {
+	m := &Map{
+		Width: 2, Height: 2, TileWidth: 16, TileHeight: 16,
+		Tilesets: []*Tileset{
+			{FirstGID: 1, Name: "terrain"},
+			{FirstGID: 10, Name: "props", Margin: 1, Columns: 4, TileCount: 8},
+		},
+		Layers: []*Layer{
+			{Name: "ground", Width: 2, Height: 2, Data: []GID{1, GID(FlippedVertically | FlippedDiagonally | 2), 3, GID(FlippedHorizontally | 9)}},
+			{Name: "props", Width: 2, Height: 2, Data: []GID{0, 10, 11, 0}},
+		},
+	}
+	w := hashira.New()
+
+	if err := AddToWorld(w, "level", m); err != nil {
+		t.Fatal(err)
+	}
+
+	hm := w.Maps.Get("level")
+	if hm == nil {
+		t.Fatal("map was not added")
+	}
+	ground := hm.Layers.Get("ground")
+	want := [][]int{{0, 1 | hashira.TileFlipVertical | hashira.TileFlipDiagonal}, {2, 8 | hashira.TileFlipHorizontal}}
+	if !reflect.DeepEqual(ground.Data, want) {
+		t.Errorf("got ground %v, want %v", ground.Data, want)
+	}
+	if ground.Tileset != "terrain" || ground.Z != 0 {
+		t.Errorf("got ground tileset %q z %v", ground.Tileset, ground.Z)
+	}
+	props := hm.Layers.Get("props")
+	if want := [][]int{{EmptyTile, 0}, {1, EmptyTile}}; !reflect.DeepEqual(props.Data, want) {
+		t.Errorf("got props %v, want %v", props.Data, want)
+	}
+	if props.Tileset != "props" || props.Z != 1 {
+		t.Errorf("got props tileset %q z %v", props.Tileset, props.Z)
+	}
+	if got, want := w.Resources.Layouts.Get("props"), (hashira.TilesetLayout{Margin: 1, Columns: 4, TileCount: 8}); got != want {
+		t.Errorf("got props layout %+v, want %+v", got, want)
+	}
+	if w.Resources.Layouts.Has("terrain") {
+		t.Error("tileset without layout should not be remembered")
+	}
+
+	// empty cells are not drawn
+	index := hm.SubMeshIndexByName.Get("props")
+	for _, cell := range [][2]int{{0, 0}, {1, 1}, {1, 0}} {
+		c, quad := hm.ChunkTile(cell[0], cell[1])
+		hidden := c.Mesh.SubMeshes[index].Indices.QuadHidden(quad)
+		if want := props.Data[cell[1]][cell[0]] == EmptyTile; hidden != want {
+			t.Errorf("props cell %v: got hidden %v, want %v", cell, hidden, want)
+		}
+	}
+}
+
+func TestAddToWorldMixedTilesets(t *testing.T) {
+	m := &Map{
+		Width: 2, Height: 1, TileWidth: 16, TileHeight: 16,
+		Tilesets: []*Tileset{{FirstGID: 1, Name: "a"}, {FirstGID: 10, Name: "b"}},
+		Layers:   []*Layer{{Name: "mixed", Width: 2, Height: 1, Data: []GID{1, 10}}},
+	}
+	w := hashira.New()
+
+	err := AddToWorld(w, "level", m)
+
+	if err == nil || !strings.Contains(err.Error(), "mixing tilesets") {
+		t.Errorf("got error %v, want mixing tilesets error", err)
+	}
+	if w.Maps.Has("level") {
+		t.Error("map should not be added on error")
+	}
+}
@@ -0,0 +1,126 @@
+package htiled
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type tmjMap struct {
+	Orientation string       `json:"orientation"`
+	Infinite    bool         `json:"infinite"`
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	TileWidth   int          `json:"tilewidth"`
+	TileHeight  int          `json:"tileheight"`
+	Layers      []tmjLayer   `json:"layers"`
+	Tilesets    []tmjTileset `json:"tilesets"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Layers      []tmjLayer      `json:"layers"`
+}
+
+type tmjTileset struct {
+	FirstGID  uint32 `json:"firstgid"`
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Image     string `json:"image"`
+	Margin    int    `json:"margin"`
+	Spacing   int    `json:"spacing"`
+	Columns   int    `json:"columns"`
+	TileCount int    `json:"tilecount"`
+}
+
+// ParseTMJ parses Tiled map in JSON format.
+func ParseTMJ(data []byte) (*Map, error) {
+	var tm tmjMap
+	if err := json.Unmarshal(data, &tm); err != nil {
+		return nil, fmt.Errorf("error decoding tmj: %v", err)
+	}
+	if err := checkOrientation(tm.Orientation, tm.Infinite); err != nil {
+		return nil, err
+	}
+
+	m := &Map{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+		Tilesets:   make([]*Tileset, 0, len(tm.Tilesets)),
+		Layers:     make([]*Layer, 0, len(tm.Layers)),
+	}
+	for _, t := range tm.Tilesets {
+		m.Tilesets = append(m.Tilesets, &Tileset{
+			FirstGID:  t.FirstGID,
+			Name:      t.Name,
+			Source:    t.Source,
+			Image:     t.Image,
+			Margin:    t.Margin,
+			Spacing:   t.Spacing,
+			Columns:   t.Columns,
+			TileCount: t.TileCount,
+		})
+	}
+	if err := m.addTMJLayers(tm.Layers); err != nil {
+		return nil, err
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Map) addTMJLayers(layers []tmjLayer) error {
+	for _, l := range layers {
+		switch l.Type {
+		case "group":
+			if err := m.addTMJLayers(l.Layers); err != nil {
+				return err
+			}
+		case "tilelayer":
+			data, err := decodeTMJLayerData(l)
+			if err != nil {
+				return fmt.Errorf("layer %q: %v", l.Name, err)
+			}
+			m.Layers = append(m.Layers, &Layer{
+				Name:   l.Name,
+				Width:  l.Width,
+				Height: l.Height,
+				Data:   data,
+			})
+		}
+	}
+	return nil
+}
+
+func decodeTMJLayerData(l tmjLayer) ([]GID, error) {
+	switch l.Encoding {
+	case "", "csv":
+		var gids []uint32
+		if err := json.Unmarshal(l.Data, &gids); err != nil {
+			return nil, fmt.Errorf("error decoding data: %v", err)
+		}
+		data := make([]GID, len(gids))
+		for i, gid := range gids {
+			data[i] = GID(gid)
+		}
+		return data, nil
+
+	case "base64":
+		var s string
+		if err := json.Unmarshal(l.Data, &s); err != nil {
+			return nil, fmt.Errorf("error decoding data: %v", err)
+		}
+		return decodeBase64(s, l.Compression)
+
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %v", l.Encoding)
+	}
+}
@@ -0,0 +1,131 @@
+package htiled
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type tmxMap struct {
+	Orientation string       `xml:"orientation,attr"`
+	Infinite    int          `xml:"infinite,attr"`
+	Width       int          `xml:"width,attr"`
+	Height      int          `xml:"height,attr"`
+	TileWidth   int          `xml:"tilewidth,attr"`
+	TileHeight  int          `xml:"tileheight,attr"`
+	Tilesets    []tmxTileset `xml:"tileset"`
+	// layers and groups must be kept in document order
+	Children []tmxLayer `xml:",any"`
+}
+
+type tmxTileset struct {
+	FirstGID  uint32 `xml:"firstgid,attr"`
+	Name      string `xml:"name,attr"`
+	Source    string `xml:"source,attr"`
+	Margin    int    `xml:"margin,attr"`
+	Spacing   int    `xml:"spacing,attr"`
+	Columns   int    `xml:"columns,attr"`
+	TileCount int    `xml:"tilecount,attr"`
+	Image     struct {
+		Source string `xml:"source,attr"`
+	} `xml:"image"`
+}
+
+type tmxLayer struct {
+	XMLName  xml.Name
+	Name     string     `xml:"name,attr"`
+	Width    int        `xml:"width,attr"`
+	Height   int        `xml:"height,attr"`
+	Data     tmxData    `xml:"data"`
+	Children []tmxLayer `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+// ParseTMX parses Tiled map in XML format.
+func ParseTMX(data []byte) (*Map, error) {
+	var tm tmxMap
+	if err := xml.Unmarshal(data, &tm); err != nil {
+		return nil, fmt.Errorf("error decoding tmx: %v", err)
+	}
+	if err := checkOrientation(tm.Orientation, tm.Infinite != 0); err != nil {
+		return nil, err
+	}
+
+	m := &Map{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+		Tilesets:   make([]*Tileset, 0, len(tm.Tilesets)),
+		Layers:     make([]*Layer, 0),
+	}
+	for _, t := range tm.Tilesets {
+		m.Tilesets = append(m.Tilesets, &Tileset{
+			FirstGID:  t.FirstGID,
+			Name:      t.Name,
+			Source:    t.Source,
+			Image:     t.Image.Source,
+			Margin:    t.Margin,
+			Spacing:   t.Spacing,
+			Columns:   t.Columns,
+			TileCount: t.TileCount,
+		})
+	}
+	if err := m.addTMXLayers(tm.Children); err != nil {
+		return nil, err
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Map) addTMXLayers(layers []tmxLayer) error {
+	for _, l := range layers {
+		switch l.XMLName.Local {
+		case "group":
+			if err := m.addTMXLayers(l.Children); err != nil {
+				return err
+			}
+		case "layer":
+			data, err := decodeTMXLayerData(l.Data)
+			if err != nil {
+				return fmt.Errorf("layer %q: %v", l.Name, err)
+			}
+			m.Layers = append(m.Layers, &Layer{
+				Name:   l.Name,
+				Width:  l.Width,
+				Height: l.Height,
+				Data:   data,
+			})
+		}
+	}
+	return nil
+}
+
+func decodeTMXLayerData(d tmxData) ([]GID, error) {
+	switch d.Encoding {
+	case "":
+		data := make([]GID, len(d.Tiles))
+		for i, t := range d.Tiles {
+			data[i] = GID(t.GID)
+		}
+		return data, nil
+
+	case "csv":
+		return decodeCSV(d.Text)
+
+	case "base64":
+		return decodeBase64(d.Text, d.Compression)
+
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %v", d.Encoding)
+	}
+}
@@ -10,7 +10,8 @@ class HashiraEditor {
 
     bindEvents = (canvas) => {
         canvas.addEventListener("mousedown", this.onCanvasMouseDown);
-        canvas.addEventListener("mouseup", this.onCanvasMouseUp);
+        // drag ends also when released outside of the canvas
+        window.addEventListener("mouseup", this.onCanvasMouseUp);
         canvas.addEventListener("mousemove", this.onCanvasMouseMove);
         canvas.addEventListener("mousemove", this.onCanvasMouseMove);
         canvas.addEventListener("contextmenu", e => e.preventDefault());
@@ -23,11 +24,16 @@ class HashiraEditor {
             this.dragStartX = e.clientX;
             this.dragStartY = e.clientY;
             this.dragging = true;
+        } else {
+            this.hashira.clickPointer(e.offsetX, e.offsetY);
         }
     }
 
     onCanvasMouseUp = (e) => {
-        e.preventDefault();
+        if (this.dragging) {
+            e.preventDefault();
+            this.hashira.releaseCameraDrag();
+        }
         this.dragging = false;
     }
 
@@ -44,6 +50,8 @@ class HashiraEditor {
             this.dragStartY = y;
 
             this._rightMBDraggedBy(dx, dy);
+        } else {
+            this.hashira.movePointer(e.offsetX, e.offsetY);
         }
     }
 
@@ -54,7 +62,7 @@ class HashiraEditor {
     }
 
     _rightMBDraggedBy = (dx, dy) => {
-        this.hashira.setCameraTranslationBy(-dx, dy);
+        this.hashira.dragCamera(-dx, dy);
     }
 
     _isRightMB = (e) => {
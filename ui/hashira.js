@@ -1,55 +1,276 @@
 "use strict";
 
 const Hashira = {
-    Fetch: (wasmURL) => {
-        return new Promise((resolve, reject) => {
-            if (!WebAssembly.instantiateStreaming) {
-                WebAssembly.instantiateStreaming = async (resp, importObject) => {
-                    const source = await (await resp).arrayBuffer();
-                    return await WebAssembly.instantiate(source, importObject);
-                };
-            }
+    // wasm runtime is loaded once and shared by all clients
+    runtime: null,
 
-            let go = new Go();
-            WebAssembly.instantiateStreaming(fetch(wasmURL), go.importObject).then(
-                (result) => {
-                    go.run(result.instance)
-                    // TODO: in the future this should be instance based
-                    // once multiple instances are supported by Hashira
-                    resolve(new HashiraClient(null));
+    // resolves with a new client, call bindCanvasByID to create its instance
+    Fetch: (wasmURL) => {
+        if (!Hashira.runtime) {
+            Hashira.runtime = new Promise((resolve, reject) => {
+                if (!WebAssembly.instantiateStreaming) {
+                    WebAssembly.instantiateStreaming = async (resp, importObject) => {
+                        const source = await (await resp).arrayBuffer();
+                        return await WebAssembly.instantiate(source, importObject);
+                    };
                 }
-            );
-        });
+
+                let go = new Go();
+                WebAssembly.instantiateStreaming(fetch(wasmURL), go.importObject).then(
+                    (result) => {
+                        go.run(result.instance)
+                        resolve();
+                    }
+                ).catch(reject);
+            });
+        }
+        return Hashira.runtime.then(() => new HashiraClient(null));
     }
 };
 
+// Cells with empty tile draw nothing, lower layers stay visible.
+const HashiraEmptyTile = -1;
+
+// Flip flags stored in high bits of tile ids (layer data and setTile),
+// diagonal flip is applied first, then horizontal and vertical (same as in Tiled).
+const HashiraTileFlip = {
+    horizontal: 1 << 30,
+    vertical: 1 << 29,
+    diagonal: 1 << 28,
+};
+
+// Encodes payloads for sendBinaryEvent of the instance handle (little endian):
+// string - uint32 length + UTF-8 bytes, int - int32, bytes - raw bytes.
+class HashiraBinaryWriter {
+    constructor() {
+        this.parts = [];
+        this.length = 0;
+    }
+
+    string = (s) => {
+        const bytes = new TextEncoder().encode(s || "");
+        this.int(bytes.length);
+        return this.bytes(bytes);
+    }
+
+    int = (v) => {
+        return this.ints([v]);
+    }
+
+    ints = (values) => {
+        const bytes = new Uint8Array(values.length * 4);
+        const view = new DataView(bytes.buffer);
+        values.forEach((v, i) => view.setInt32(i * 4, v, true));
+        return this.bytes(bytes);
+    }
+
+    bytes = (bytes) => {
+        const b = new Uint8Array(bytes);
+        this.parts.push(b);
+        this.length += b.length;
+        return this;
+    }
+
+    toBytes = () => {
+        const out = new Uint8Array(this.length);
+        let offset = 0;
+        this.parts.forEach((b) => {
+            out.set(b, offset);
+            offset += b.length;
+        });
+        return out;
+    }
+}
+
 class HashiraClient {
     constructor(instance) {
         this.instance = instance;
+        this.worldExports = [];
+        this.screenshots = [];
+        this.listeners = {};
+        this.on("WorldSerialized", (bytes) => {
+            const pending = this.worldExports.shift();
+            if (pending) {
+                pending.resolve(bytes);
+            }
+        });
+        // replies come in request order, failed request gets an error instead
+        this.on("ErrorOccurred", (e) => {
+            const requests = {
+                WorldExported: this.worldExports,
+                ScreenshotRequested: this.screenshots,
+            }[e.event];
+            const pending = requests && requests.shift();
+            if (pending) {
+                pending.reject(new Error(e.message));
+            }
+        });
+        this.on("ScreenshotTaken", (bytes) => {
+            const pending = this.screenshots.shift();
+            if (pending) {
+                pending.resolve(bytes);
+            }
+        });
     }
 
     bindEvents = (canvas) => {
         this.canvas = canvas;
-        window.addEventListener('resize', (e) => {
+        this.onWindowResize = (e) => {
             const width = this.canvas.clientWidth;
             const height = this.canvas.clientHeight;
             this.sendEvent("ScreenResized", { width: width, height: height });
-        }, false);
+        };
+        window.addEventListener('resize', this.onWindowResize, false);
+    }
+
+    // options (all optional):
+    //   maxEventsPerFrame - limit of events processed in a single frame (default 1000, -1 for no limit)
+    //   eventsBudgetMs - time limit for processing events in a single frame
+    //   renderMode - "mesh" (default) or "texture" (tile ids uploaded as texture, cheap tile updates)
+    //     texture mode can't draw maps larger than GPU max texture size, they are reported with ErrorOccurred
+    bindCanvasByID = (canvasID, options) => {
+        if (options) {
+            this.instance = window.HashiraInitRenderLoop(canvasID, options);
+        } else {
+            this.instance = window.HashiraInitRenderLoop(canvasID);
+        }
+        if (!this.instance) {
+            throw new Error(`Hashira: failed to initialize canvas ${canvasID}`);
+        }
+        this.instance.onEvent(this._dispatch);
     }
 
-    bindCanvasByID = (canvasID) => {
-        window.HashiraInitRenderLoop(canvasID);
+    // events sent while paused are processed after resume
+    pause = () => {
+        this.sendEvent("RenderingPaused", {});
+    }
+
+    resume = () => {
+        this.sendEvent("RenderingResumed", {});
+    }
+
+    // stops rendering and releases all resources of the instance
+    destroy = () => {
+        if (this.onWindowResize) {
+            window.removeEventListener('resize', this.onWindowResize, false);
+            this.onWindowResize = null;
+        }
+        if (this.instance) {
+            this.instance.destroy();
+            this.instance = null;
+        }
+        this.listeners = {};
     }
 
     sendEvent = (event, data) => {
-        window.HashiraSendEvent(event, JSON.stringify(data));
+        const payload = JSON.stringify(data);
+        if (this.pendingEvents) {
+            this.pendingEvents.push([event, payload]);
+            return;
+        }
+        this.instance.sendEvent(event, payload);
+    }
+
+    // bytes: Uint8Array, see HashiraBinaryWriter
+    sendBinaryEvent = (event, bytes) => {
+        if (this.pendingEvents) {
+            this.pendingEvents.push([event, bytes]);
+            return;
+        }
+        this.instance.sendBinaryEvent(event, bytes);
     }
 
-    loadTileset = (url) => {
+    // events: array of [event, data] pairs, Uint8Array data is sent as binary
+    sendEvents = (events) => {
+        this.instance.sendEvents(events.map(([event, data]) => {
+            return [event, data instanceof Uint8Array ? data : JSON.stringify(data)];
+        }));
+    }
+
+    // all events sent inside fn are delivered as a single batch
+    batch = (fn) => {
+        this.pendingEvents = [];
+        try {
+            fn();
+        } finally {
+            const events = this.pendingEvents;
+            this.pendingEvents = null;
+            if (events.length > 0) {
+                this.instance.sendEvents(events);
+            }
+        }
+    }
+
+    // name is optional, defaults to "tileset"
+    // layout is optional: { margin, spacing, columns, tileCount } in pixels and tiles (same as in Tiled),
+    // columns and tileCount are computed from image size when not given
+    loadTileset = (url, name, layout) => {
+        const l = layout || {};
         return fetch(url).then((response) => {
             return response.arrayBuffer();
         }).then((buffer) => {
-            this.sendEvent("TilesetLoaded", { bytes: Array.from(new Uint8Array(buffer)) });
+            const payload = new HashiraBinaryWriter()
+                .string(name)
+                .ints([l.margin || 0, l.spacing || 0, l.columns || 0, l.tileCount || 0])
+                .bytes(buffer)
+                .toBytes();
+            this.sendBinaryEvent("TilesetLoaded", payload);
+        });
+    }
+
+    // packs loaded tilesets into a single texture, tile borders are repeated extrude times (1 by default)
+    // to avoid seams between tiles at fractional zooms, tilesets have to be packed again after reloading
+    packTilesets = (atlasName, tilesetNames, tileWidth, tileHeight, extrude) => {
+        this.sendEvent("TilesetsPacked", {
+            name: atlasName,
+            tilesets: tilesetNames,
+            tile_width: tileWidth,
+            tile_height: tileHeight,
+            extrude: extrude === undefined ? 1 : extrude,
+        });
+    }
+
+    // accepts both .tmx and .tmj files,
+    // tilesets used by the map must be loaded with loadTileset using Tiled tileset names
+    loadTiledMap = (url, mapName) => {
+        return fetch(url).then((response) => {
+            return response.arrayBuffer();
+        }).then((buffer) => {
+            this.sendBinaryEvent("TiledMapLoaded", new HashiraBinaryWriter().string(mapName).bytes(buffer).toBytes());
+        });
+    }
+
+    // format: "json" (default) or "binary"
+    // resolves with Uint8Array, rejects when the world can't be saved
+    exportWorld = (format) => {
+        return new Promise((resolve, reject) => {
+            this.worldExports.push({ resolve: resolve, reject: reject });
+            this.sendEvent("WorldExported", { format: format });
+        });
+    }
+
+    // resolves with PNG bytes (Uint8Array) of the last rendered frame, rejects when encoding fails
+    screenshot = () => {
+        return new Promise((resolve, reject) => {
+            this.screenshots.push({ resolve: resolve, reject: reject });
+            this.sendEvent("ScreenshotRequested", {});
+        });
+    }
+
+    // bytes: ArrayBuffer or Uint8Array returned by exportWorld
+    loadWorld = (bytes) => {
+        this.sendBinaryEvent("WorldLoaded", new HashiraBinaryWriter().bytes(bytes).toBytes());
+    }
+
+    // options: { filter: "nearest" | "linear", mipmaps: bool, wrap: "clamp" | "repeat" | "mirror" }
+    // textureName is a tileset or atlas name, when omitted options become defaults of all textures
+    // e.g. setTextureFiltering({ mipmaps: true }) stops shimmering when zoomed out
+    setTextureFiltering = (options, textureName) => {
+        this.sendEvent("TextureFilteringSet", {
+            texture: textureName,
+            filter: options.filter,
+            mipmaps: options.mipmaps,
+            wrap: options.wrap,
         });
     }
 
@@ -61,16 +282,79 @@ class HashiraClient {
         this.sendEvent("MapAdded", { name: name, width: width, height: height, tile_width: tileWidth, tile_height: tileHeight });
     }
 
-    addLayer = (mapName, layerName, z) => {
-        this.sendEvent("LayerAdded", { map: mapName, name: layerName, z: z });
+    // tilesetName is optional, defaults to "tileset"
+    addLayer = (mapName, layerName, z, tilesetName) => {
+        this.sendEvent("LayerAdded", { map: mapName, name: layerName, z: z, tileset: tilesetName });
     }
 
     addLayerData = (mapName, layerName, data) => {
-        this.sendEvent("LayerDataAdded", { map: mapName, layer: layerName, data: data });
+        const height = data.length;
+        const width = height > 0 ? data[0].length : 0;
+        const payload = new HashiraBinaryWriter()
+            .string(mapName)
+            .string(layerName)
+            .int(width)
+            .int(height)
+            .ints(data.flat())
+            .toBytes();
+        this.sendBinaryEvent("LayerDataAdded", payload);
     }
 
+    // tileID can be combined with flip flags, e.g. tileID | HashiraTileFlip.horizontal
     setTile = (mapName, layerName, x, y, tileID) => {
-        this.sendEvent("TileAssigned", { map: mapName, layer: layerName, x: x, y: y, tile: tileID });
+        const payload = new HashiraBinaryWriter()
+            .string(mapName)
+            .string(layerName)
+            .ints([x, y, tileID])
+            .toBytes();
+        this.sendBinaryEvent("TileAssigned", payload);
+    }
+
+    // frames[0] is the base tile, all tiles with this id will be animated
+    // delay is in seconds, needs at least 2 frames and positive delay
+    defineAnimation = (frames, delay) => {
+        this.sendEvent("AnimationDefined", { frames: frames, delay: delay });
+    }
+
+    clearAnimations = () => {
+        this.sendEvent("AnimationsCleared", {});
+    }
+
+    // x, y in CSS pixels relative to canvas
+    movePointer = (x, y) => {
+        this.sendEvent("PointerMoved", { x: x, y: y });
+    }
+
+    // x, y in CSS pixels relative to canvas
+    clickPointer = (x, y) => {
+        this.sendEvent("PointerClicked", { x: x, y: y });
+    }
+
+    // fn receives { map, layer, x, y, tile }
+    onTileHovered = (fn) => {
+        return this.on("TileHovered", fn);
+    }
+
+    // fn receives { map, layer, x, y, tile }
+    onTileClicked = (fn) => {
+        return this.on("TileClicked", fn);
+    }
+
+    // subscribes to events sent by Hashira:
+    // ErrorOccurred, TilesetReady, MapReady, FrameStats, TileHovered, TileClicked, WorldSerialized
+    // returns function that unsubscribes
+    on = (event, fn) => {
+        this.listeners[event] = this.listeners[event] || [];
+        this.listeners[event].push(fn);
+        return () => {
+            this.listeners[event] = this.listeners[event].filter((f) => f !== fn);
+        };
+    }
+
+    _dispatch = (event, payload) => {
+        const listeners = this.listeners[event] || [];
+        const data = typeof payload === "string" ? JSON.parse(payload) : payload;
+        listeners.forEach((fn) => fn(data));
     }
 
     setCameraZoom = (zoom) => {
@@ -78,7 +362,7 @@ class HashiraClient {
     }
 
     setCameraZoomBy = (by) => {
-        this.sendEvent("CammeraZoomedBy", { delta: by });
+        this.sendEvent("CameraZoomedBy", { delta: by });
     }
 
     setCameraTranslation = (x, y) => {
@@ -89,7 +373,62 @@ class HashiraClient {
         this.sendEvent("CameraTranslatedBy", { x: x, y: y });
     }
 
+    // like setCameraTranslationBy, but stops following and keeps moving after releaseCameraDrag
+    dragCamera = (x, y) => {
+        this.sendEvent("CameraDragged", { x: x, y: y });
+    }
+
     setCameraToMapCenter = (mapName) => {
         this.sendEvent("CameraTranslatedToMapCenter", { map: mapName });
     }
+
+    // duration in seconds, zoom 0 keeps the current zoom,
+    // easing: "linear" (default), "inQuad", "outQuad", "inOutQuad", "outCubic"
+    moveCameraTo = (x, y, options) => {
+        options = options || {};
+        this.sendEvent("CameraMovedTo", {
+            x: x,
+            y: y,
+            zoom: options.zoom || 0,
+            duration: options.duration || 0,
+            easing: options.easing || "",
+        });
+    }
+
+    // call again when the target moves, speed defaults to 5
+    followCamera = (x, y, speed) => {
+        this.sendEvent("CameraFollowed", { x: x, y: y, speed: speed || 0 });
+    }
+
+    stopCameraFollow = () => {
+        this.sendEvent("CameraFollowStopped", {});
+    }
+
+    // camera keeps moving after drag (dragCamera) is released
+    releaseCameraDrag = () => {
+        this.sendEvent("CameraDragReleased", {});
+    }
+
+    // bounds: map name or { minX, minY, maxX, maxY } in world units
+    setCameraBounds = (bounds) => {
+        if (typeof bounds === "string") {
+            this.sendEvent("CameraBoundsSet", { map: bounds });
+        } else {
+            this.sendEvent("CameraBoundsSet", {
+                min_x: bounds.minX,
+                min_y: bounds.minY,
+                max_x: bounds.maxX,
+                max_y: bounds.maxY,
+            });
+        }
+    }
+
+    clearCameraBounds = () => {
+        this.sendEvent("CameraBoundsCleared", {});
+    }
+
+    // zoomStep defaults to 1 (integer zoom)
+    setCameraPixelPerfect = (enabled, zoomStep) => {
+        this.sendEvent("CameraPixelPerfectSet", { enabled: enabled, zoom_step: zoomStep || 0 });
+    }
 }